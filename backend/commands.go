@@ -0,0 +1,120 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/Stormdead/inventory-control-panel/backend/config"
+	"github.com/Stormdead/inventory-control-panel/backend/controllers"
+	"github.com/Stormdead/inventory-control-panel/backend/models"
+)
+
+// runCommand ejecuta un subcomando de línea de comandos en lugar del servidor
+func runCommand(name string, args []string) {
+	switch name {
+	case "reconcile":
+		reconcileCommand(args)
+	case "snapshot":
+		snapshotCommand(args)
+	case "bootstrap-admin":
+		bootstrapAdminCommand(args)
+	default:
+		log.Fatalf("Comando desconocido: %s", name)
+	}
+}
+
+// reconcile [-fix] [-user ID]: compara el stock con los movimientos y opcionalmente lo corrige.
+// Termina con código 1 si hay diferencias sin corregir (útil para tareas programadas).
+func reconcileCommand(args []string) {
+	fs := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	fix := fs.Bool("fix", false, "registrar movimientos de ajuste para las diferencias")
+	userID := fs.Uint("user", 0, "ID del usuario que firma los ajustes (por defecto el primer admin)")
+	fs.Parse(args)
+
+	var discrepancies []controllers.StockDiscrepancy
+	var err error
+
+	if *fix {
+		if *userID == 0 {
+			var admin models.User
+			if err := config.DB.Where("role = ?", "admin").Order("id").First(&admin).Error; err != nil {
+				log.Fatal("No se encontró un usuario admin para firmar los ajustes")
+			}
+			*userID = admin.ID
+		}
+		discrepancies, err = controllers.ReconcileStock(config.DB, *userID)
+	} else {
+		discrepancies, err = controllers.FindStockDiscrepancies(config.DB)
+	}
+	if err != nil {
+		log.Fatal("Error en la reconciliación:", err)
+	}
+
+	for _, d := range discrepancies {
+		fmt.Printf("Producto #%d %q en %q: stock %d, según movimientos %d (diferencia %+d)",
+			d.ProductID, d.ProductName, d.LocationName, d.StoredStock, d.LedgerStock, d.Difference)
+		if d.AdjustmentMovementID != nil {
+			fmt.Printf(" -> ajuste #%d", *d.AdjustmentMovementID)
+		}
+		fmt.Println()
+	}
+	fmt.Printf("%d diferencia(s) de stock\n", len(discrepancies))
+
+	if len(discrepancies) > 0 && !*fix {
+		os.Exit(1)
+	}
+}
+
+// snapshot [-month YYYY-MM] [-lock]: toma la foto de cierre de un mes (por defecto el mes
+// anterior). Pensado para ejecutarse desde una tarea programada a principio de mes.
+func snapshotCommand(args []string) {
+	fs := flag.NewFlagSet("snapshot", flag.ExitOnError)
+	month := fs.String("month", time.Now().AddDate(0, -1, 0).Format("2006-01"), "mes a cerrar (YYYY-MM)")
+	lock := fs.Bool("lock", false, "bloquear el cierre para que no se pueda modificar")
+	fs.Parse(args)
+
+	asOf, err := controllers.MonthEnd(*month)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if asOf.After(time.Now()) {
+		log.Fatalf("El mes %s todavía no terminó", *month)
+	}
+
+	snapshot, err := controllers.CreateStockSnapshot(config.DB, asOf, models.SnapshotTypeMensual, nil)
+	if err != nil {
+		log.Fatal("Error al tomar la foto de stock:", err)
+	}
+
+	if *lock {
+		now := time.Now()
+		if err := config.DB.Model(snapshot).Updates(map[string]interface{}{"locked": true, "locked_at": now}).Error; err != nil {
+			log.Fatal("Error al bloquear el cierre:", err)
+		}
+	}
+
+	fmt.Printf("Foto de stock #%d al %s (bloqueada: %v)\n", snapshot.ID, asOf.Format("2006-01-02"), *lock)
+}
+
+// bootstrap-admin -username U -email E -password P: crea el admin inicial.
+// Solo funciona mientras no haya usuarios registrados.
+func bootstrapAdminCommand(args []string) {
+	fs := flag.NewFlagSet("bootstrap-admin", flag.ExitOnError)
+	username := fs.String("username", "admin", "nombre de usuario del admin")
+	email := fs.String("email", "", "email del admin")
+	password := fs.String("password", os.Getenv("ADMIN_PASSWORD"), "contraseña (por defecto ADMIN_PASSWORD)")
+	fs.Parse(args)
+
+	if *email == "" || len(*password) < 6 {
+		log.Fatal("Se requieren -email y una contraseña de al menos 6 caracteres")
+	}
+
+	user, err := controllers.BootstrapAdmin(config.DB, *username, *email, *password)
+	if err != nil {
+		log.Fatal("Error al crear el admin inicial: ", err)
+	}
+	fmt.Printf("Admin inicial #%d %q creado\n", user.ID, user.Username)
+}
@@ -36,10 +36,73 @@ func ConnectDB() {
 		&models.Category{},
 		&models.Product{},
 		&models.Movement{},
+		&models.IdempotencyKey{},
+		&models.Location{},
+		&models.ProductStock{},
+		&models.Transfer{},
+		&models.ReasonCode{},
+		&models.CountSession{},
+		&models.CountLine{},
+		&models.ProductBarcode{},
+		&models.CostLayer{},
+		&models.StockSnapshot{},
+		&models.StockSnapshotLine{},
+		&models.Supplier{},
+		&models.PurchaseOrder{},
+		&models.PurchaseOrderLine{},
+		&models.SalesOrder{},
+		&models.SalesOrderLine{},
+		&models.RefreshToken{},
+		&models.Invitation{},
+		&models.Role{},
 	)
 	if err != nil {
 		log.Fatal("Error en la migración:", err)
 	}
 
 	log.Println("Tablas creadas/actualizadas correctamente")
+
+	seedDefaultLocation()
+	seedReasonCodes()
+	seedRoles()
+}
+
+// seedReasonCodes crea los motivos del catálogo inicial que todavía no existen
+func seedReasonCodes() {
+	for _, reason := range models.DefaultReasonCodes {
+		if err := DB.Where(models.ReasonCode{Code: reason.Code}).FirstOrCreate(&reason).Error; err != nil {
+			log.Fatal("Error creando los motivos de movimiento:", err)
+		}
+	}
+}
+
+// seedRoles crea los roles iniciales que todavía no existen
+func seedRoles() {
+	for _, role := range models.DefaultRoles {
+		if err := DB.Where(models.Role{Name: role.Name}).FirstOrCreate(&role).Error; err != nil {
+			log.Fatal("Error creando los roles:", err)
+		}
+	}
+}
+
+// seedDefaultLocation crea la ubicación por defecto y asigna a ella el stock
+// de los productos que todavía no tienen stock por ubicación
+func seedDefaultLocation() {
+	var location models.Location
+	err := DB.Where(models.Location{IsDefault: true}).
+		Attrs(models.Location{Name: "Almacén principal", Code: "PRINCIPAL"}).
+		FirstOrCreate(&location).Error
+	if err != nil {
+		log.Fatal("Error creando la ubicación por defecto:", err)
+	}
+
+	err = DB.Exec(`
+		INSERT INTO product_stocks (product_id, location_id, quantity, updated_at)
+		SELECT p.id, ?, p.stock, NOW()
+		FROM products p
+		WHERE p.stock <> 0
+		AND NOT EXISTS (SELECT 1 FROM product_stocks ps WHERE ps.product_id = p.id)`, location.ID).Error
+	if err != nil {
+		log.Fatal("Error asignando stock a la ubicación por defecto:", err)
+	}
 }
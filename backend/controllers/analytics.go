@@ -0,0 +1,491 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Stormdead/inventory-control-panel/backend/config"
+	"github.com/Stormdead/inventory-control-panel/backend/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// analyticsPeriod es el período y la categoría de un reporte de análisis
+type analyticsPeriod struct {
+	From       time.Time
+	To         time.Time
+	CategoryID string
+}
+
+// days devuelve la duración del período en días (al menos 1)
+func (p analyticsPeriod) days() float64 {
+	return math.Max(1, p.To.Sub(p.From).Hours()/24)
+}
+
+// withProducts une la consulta (alias t, con product_id) a los productos vigentes (alias p)
+// y sus categorías (alias c), filtrando por categoría si se indicó
+func (p analyticsPeriod) withProducts(query *gorm.DB) *gorm.DB {
+	query = query.Joins("JOIN products p ON p.id = t.product_id AND p.deleted_at IS NULL").
+		Joins("LEFT JOIN categories c ON c.id = p.category_id")
+	if p.CategoryID != "" {
+		query = query.Where("p.category_id = ?", p.CategoryID)
+	}
+	return query
+}
+
+// consumption devuelve el consumo del período por producto: cantidad y costo de venta
+// de las salidas no revertidas
+func (p analyticsPeriod) consumption() *gorm.DB {
+	return summaryMovements("", p.From, p.To).
+		Select("m.product_id, SUM(m.quantity) as quantity, SUM(m.cost_amount) as value").
+		Where(models.ConsumptionSQL("m")).
+		Group("m.product_id")
+}
+
+// parseAnalyticsPeriod lee ?from=&to=&category_id=. Por defecto los últimos 90 días.
+func parseAnalyticsPeriod(c *gin.Context) (analyticsPeriod, error) {
+	from, to, err := parsePeriod(c, 90)
+	return analyticsPeriod{From: from, To: to, CategoryID: c.Query("category_id")}, err
+}
+
+// queryFloat lee un umbral decimal positivo con valor por defecto
+func queryFloat(c *gin.Context, name string, def float64) (float64, error) {
+	value, err := strconv.ParseFloat(c.DefaultQuery(name, strconv.FormatFloat(def, 'f', -1, 64)), 64)
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("%s debe ser un número positivo", name)
+	}
+	return value, nil
+}
+
+// GET /api/dashboard/analytics/abc - Clasificación ABC por valor de consumo (costo de venta)
+// (?from=&to=&category_id=&a=80&b=95&class=A)
+//
+// Los productos se ordenan por valor consumido; son A mientras el acumulado anterior no
+// llegue al a% del total, B mientras no llegue al b% y C el resto.
+func GetABCAnalysis(c *gin.Context) {
+	period, err := parseAnalyticsPeriod(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limitA, err := queryFloat(c, "a", 80)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limitB, err := queryFloat(c, "b", 95)
+	if err != nil || limitB <= limitA || limitB > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "b debe ser un porcentaje mayor que a y hasta 100"})
+		return
+	}
+
+	type ABCLine struct {
+		ProductID    uint    `json:"product_id"`
+		SKU          *string `json:"sku"`
+		ProductName  string  `json:"product_name"`
+		CategoryName *string `json:"category_name"`
+		Quantity     int     `json:"quantity"`
+		Value        float64 `json:"value"`
+		Share        float64 `json:"share"`      // Porcentaje del valor total
+		Cumulative   float64 `json:"cumulative"` // Porcentaje acumulado
+		Class        string  `json:"class"`
+	}
+
+	ranked := period.withProducts(config.DB.Table("(?) as t", period.consumption())).
+		Select("t.product_id, p.sku, p.name as product_name, c.name as category_name, t.quantity, t.value, " +
+			"SUM(t.value) OVER (ORDER BY t.value DESC, t.product_id) as running, SUM(t.value) OVER () as total").
+		Where("t.value > 0")
+
+	query := config.DB.Table("(?) as r", ranked).
+		Select(`r.product_id, r.sku, r.product_name, r.category_name, r.quantity, r.value,
+			ROUND(100 * r.value / r.total, 2) as share,
+			ROUND(100 * r.running / r.total, 2) as cumulative,
+			CASE WHEN 100 * (r.running - r.value) / r.total < ? THEN 'A'
+				WHEN 100 * (r.running - r.value) / r.total < ? THEN 'B'
+				ELSE 'C' END as class`, limitA, limitB).
+		Order("r.running")
+	if class := c.Query("class"); class != "" {
+		query = config.DB.Table("(?) as k", query).Where("k.class = ?", class).Order("k.cumulative")
+	}
+
+	var lines []ABCLine
+	if err := query.Scan(&lines).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al calcular la clasificación ABC"})
+		return
+	}
+
+	summary := map[string]int{"A": 0, "B": 0, "C": 0}
+	for _, line := range lines {
+		summary[line.Class]++
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"products": lines,
+		"total":    len(lines),
+		"summary":  summary,
+		"from":     period.From,
+		"to":       period.To,
+	})
+}
+
+// GET /api/dashboard/analytics/xyz - Clasificación XYZ por variabilidad de la demanda semanal
+// (?from=&to=&category_id=&x=0.5&y=1&class=X)
+//
+// La variabilidad es el coeficiente de variación (desvío / promedio) del consumo semanal,
+// contando con cero las semanas sin consumo: X hasta x, Y hasta y y Z el resto.
+func GetXYZAnalysis(c *gin.Context) {
+	period, err := parseAnalyticsPeriod(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limitX, err := queryFloat(c, "x", 0.5)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limitY, err := queryFloat(c, "y", 1)
+	if err != nil || limitY <= limitX {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "y debe ser un número mayor que x"})
+		return
+	}
+
+	type XYZLine struct {
+		ProductID     uint    `json:"product_id"`
+		SKU           *string `json:"sku"`
+		ProductName   string  `json:"product_name"`
+		CategoryName  *string `json:"category_name"`
+		Quantity      int     `json:"quantity"`
+		WeeklyAverage float64 `json:"weekly_average"`
+		WeeklyStdDev  float64 `json:"weekly_stddev"`
+		Variation     float64 `json:"variation"` // Coeficiente de variación
+		Class         string  `json:"class"`
+	}
+
+	// Consumo por producto y semana del período; las semanas sin consumo no tienen fila,
+	// por eso el promedio y la varianza se calculan sobre la cantidad total de semanas
+	weeks := math.Ceil(period.days() / 7)
+	weekly := summaryMovements("", period.From, period.To).
+		Select("m.product_id, FLOOR(DATEDIFF(m.movement_date, ?) / 7) as week, SUM(m.quantity) as quantity", period.From).
+		Where(models.ConsumptionSQL("m")).
+		Group("m.product_id, week")
+
+	stats := config.DB.Table("(?) as w", weekly).
+		Select("w.product_id, SUM(w.quantity) as quantity, SUM(w.quantity) / ? as mean, "+
+			"SQRT(GREATEST(SUM(w.quantity * w.quantity) / ? - POW(SUM(w.quantity) / ?, 2), 0)) as stddev",
+			weeks, weeks, weeks).
+		Group("w.product_id")
+
+	classified := period.withProducts(config.DB.Table("(?) as t", stats)).
+		Select(`t.product_id, p.sku, p.name as product_name, c.name as category_name, t.quantity,
+			ROUND(t.mean, 2) as weekly_average, ROUND(t.stddev, 2) as weekly_stddev,
+			ROUND(t.stddev / t.mean, 3) as variation,
+			CASE WHEN t.stddev / t.mean <= ? THEN 'X' WHEN t.stddev / t.mean <= ? THEN 'Y' ELSE 'Z' END as class`,
+			limitX, limitY).
+		Where("t.quantity > 0")
+
+	query := config.DB.Table("(?) as k", classified).Order("k.variation, k.product_id")
+	if class := c.Query("class"); class != "" {
+		query = query.Where("k.class = ?", class)
+	}
+
+	var lines []XYZLine
+	if err := query.Scan(&lines).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al calcular la clasificación XYZ"})
+		return
+	}
+
+	summary := map[string]int{"X": 0, "Y": 0, "Z": 0}
+	for _, line := range lines {
+		summary[line.Class]++
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"products": lines,
+		"total":    len(lines),
+		"summary":  summary,
+		"weeks":    weeks,
+		"from":     period.From,
+		"to":       period.To,
+	})
+}
+
+// GET /api/dashboard/analytics/turnover - Rotación del inventario y días de cobertura por producto
+// (?from=&to=&category_id=)
+//
+// Rotación = costo de venta del período / valor promedio del inventario (inicio y fin del
+// período, a costo promedio). Días de cobertura = stock al final / consumo diario del período.
+func GetInventoryTurnover(c *gin.Context) {
+	period, err := parseAnalyticsPeriod(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	type TurnoverLine struct {
+		ProductID    uint     `json:"product_id"`
+		SKU          *string  `json:"sku"`
+		ProductName  string   `json:"product_name"`
+		CategoryName *string  `json:"category_name"`
+		OpeningValue float64  `json:"opening_value"`
+		ClosingValue float64  `json:"closing_value"`
+		ClosingStock int      `json:"closing_stock"`
+		Consumed     int      `json:"consumed"`
+		COGS         float64  `json:"cogs"`
+		Turnover     *float64 `json:"turnover"`       // nil sin inventario promedio
+		DaysOfSupply *float64 `json:"days_of_supply"` // nil sin consumo
+	}
+
+	// Un solo recorrido del libro hasta el fin del período: valor al inicio, valor y stock
+	// al final, y consumo y costo de venta dentro del período
+	costDelta := models.CostDeltaSQL("m", models.CostColumn(models.ValuationAverage))
+	inPeriod := "m.movement_date >= ? AND " + models.ConsumptionSQL("m")
+	ledger := config.DB.Table("movements as m").
+		Select("m.product_id, "+
+			"SUM(CASE WHEN m.movement_date < ? THEN "+costDelta+" ELSE 0 END) as opening_value, "+
+			"SUM("+costDelta+") as closing_value, "+
+			"SUM("+models.StockDeltaSQL("m")+") as closing_stock, "+
+			"SUM(CASE WHEN "+inPeriod+" THEN m.quantity ELSE 0 END) as consumed, "+
+			"SUM(CASE WHEN "+inPeriod+" THEN m.cost_amount ELSE 0 END) as cogs",
+			period.From, period.From, period.From).
+		Where("m.movement_date <= ?", period.To).
+		Group("m.product_id")
+
+	query := period.withProducts(config.DB.Table("(?) as t", ledger)).
+		Select(`t.product_id, p.sku, p.name as product_name, c.name as category_name,
+			ROUND(t.opening_value, 2) as opening_value, ROUND(t.closing_value, 2) as closing_value,
+			t.closing_stock, t.consumed, ROUND(t.cogs, 2) as cogs,
+			ROUND(t.cogs / NULLIF((t.opening_value + t.closing_value) / 2, 0), 2) as turnover,
+			ROUND(t.closing_stock * ? / NULLIF(t.consumed, 0), 1) as days_of_supply`, period.days()).
+		Where("t.closing_stock <> 0 OR t.consumed <> 0").
+		Order("turnover DESC, t.product_id")
+
+	var lines []TurnoverLine
+	if err := query.Scan(&lines).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al calcular la rotación"})
+		return
+	}
+
+	// Totales del inventario filtrado
+	var totals struct {
+		OpeningValue float64
+		ClosingValue float64
+		COGS         float64
+	}
+	for _, line := range lines {
+		totals.OpeningValue += line.OpeningValue
+		totals.ClosingValue += line.ClosingValue
+		totals.COGS += line.COGS
+	}
+	var turnover *float64
+	if average := (totals.OpeningValue + totals.ClosingValue) / 2; average > 0 {
+		value := math.Round(totals.COGS/average*100) / 100
+		turnover = &value
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"products":       lines,
+		"total":          len(lines),
+		"total_cogs":     roundCost(totals.COGS),
+		"total_turnover": turnover,
+		"from":           period.From,
+		"to":             period.To,
+	})
+}
+
+// GET /api/dashboard/analytics/dead-stock - Productos con stock y sin movimientos en el período
+// (?from=&to=&category_id=; sin from, los últimos ?days=90 días)
+func GetDeadStock(c *gin.Context) {
+	days, ok := queryInt(c, "days", 90, 1, 3650)
+	if !ok {
+		return
+	}
+	period, err := parseAnalyticsPeriod(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if c.Query("from") == "" {
+		period.From = period.To.AddDate(0, 0, -days)
+	}
+
+	type DeadStockLine struct {
+		ProductID    uint       `json:"product_id"`
+		SKU          *string    `json:"sku"`
+		ProductName  string     `json:"product_name"`
+		CategoryName *string    `json:"category_name"`
+		Stock        int        `json:"stock"`
+		Value        float64    `json:"value"`
+		LastMovement *time.Time `json:"last_movement"`
+		DaysIdle     *int       `json:"days_idle"` // nil si nunca tuvo movimientos
+	}
+
+	lastMovements := config.DB.Table("movements as m").
+		Select("m.product_id, MAX(m.movement_date) as last_movement").
+		Where("m.movement_date <= ?", period.To).
+		Group("m.product_id")
+
+	query := config.DB.Table("products as p").
+		Select(`p.id as product_id, p.sku, p.name as product_name, c.name as category_name, p.stock,
+			ROUND(p.stock * p.average_cost, 2) as value, lm.last_movement,
+			DATEDIFF(?, lm.last_movement) as days_idle`, period.To).
+		Joins("LEFT JOIN (?) as lm ON lm.product_id = p.id", lastMovements).
+		Joins("LEFT JOIN categories c ON c.id = p.category_id").
+		Where("p.deleted_at IS NULL AND p.stock > 0").
+		Where("lm.last_movement IS NULL OR lm.last_movement < ?", period.From).
+		Order("value DESC, p.id")
+	if period.CategoryID != "" {
+		query = query.Where("p.category_id = ?", period.CategoryID)
+	}
+
+	var lines []DeadStockLine
+	if err := query.Scan(&lines).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el stock inmovilizado"})
+		return
+	}
+
+	totalValue := 0.0
+	for _, line := range lines {
+		totalValue += line.Value
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"products":    lines,
+		"total":       len(lines),
+		"total_value": roundCost(totalValue),
+		"from":        period.From,
+		"to":          period.To,
+	})
+}
+
+// Tramos de antigüedad del stock, en días desde el ingreso
+var agingBuckets = []struct {
+	Name string
+	Max  int // Días máximos del tramo; 0 sin límite
+}{
+	{"0-30", 30}, {"31-60", 60}, {"61-90", 90}, {"91-180", 180}, {"181-365", 365}, {"365+", 0},
+}
+
+// GET /api/dashboard/analytics/aging - Antigüedad del stock por tramos (?category_id=&from=&to=)
+//
+// Se calcula sobre las capas FIFO con unidades remanentes, según la fecha del movimiento
+// que las ingresó; from/to filtran por esa fecha. Las unidades recibidas por transferencia
+// cuentan desde la recepción. El stock sin capas (anterior a la valuación) se informa aparte.
+func GetStockAging(c *gin.Context) {
+	categoryID := c.Query("category_id")
+	now := time.Now()
+
+	bucketSQL := "CASE"
+	args := []interface{}{}
+	for _, bucket := range agingBuckets {
+		if bucket.Max == 0 {
+			bucketSQL += " ELSE ? END"
+			args = append(args, bucket.Name)
+			break
+		}
+		bucketSQL += " WHEN DATEDIFF(?, m.movement_date) <= ? THEN ?"
+		args = append(args, now, bucket.Max, bucket.Name)
+	}
+
+	layers := config.DB.Table("cost_layers as l").
+		Select("l.product_id, "+bucketSQL+" as bucket, l.remaining, l.remaining * l.unit_cost as value, m.movement_date", args...).
+		Joins("JOIN movements m ON m.id = l.movement_id").
+		Where("l.remaining > 0")
+	if value := c.Query("from"); value != "" {
+		from, err := parseDateParam(value, false)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		layers = layers.Where("m.movement_date >= ?", from)
+	}
+	if value := c.Query("to"); value != "" {
+		to, err := parseDateParam(value, true)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		layers = layers.Where("m.movement_date <= ?", to)
+	}
+
+	var rows []struct {
+		ProductID    uint
+		SKU          *string
+		ProductName  string
+		CategoryName *string
+		Bucket       string
+		Quantity     int
+		Value        float64
+		Oldest       time.Time
+	}
+	query := analyticsPeriod{CategoryID: categoryID}.withProducts(config.DB.Table("(?) as t", layers)).
+		Select("t.product_id, p.sku, p.name as product_name, c.name as category_name, t.bucket, " +
+			"SUM(t.remaining) as quantity, SUM(t.value) as value, MIN(t.movement_date) as oldest").
+		Group("t.product_id, p.sku, p.name, c.name, t.bucket").
+		Order("t.product_id")
+	if err := query.Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al calcular la antigüedad del stock"})
+		return
+	}
+
+	type AgingLine struct {
+		ProductID    uint               `json:"product_id"`
+		SKU          *string            `json:"sku"`
+		ProductName  string             `json:"product_name"`
+		CategoryName *string            `json:"category_name"`
+		Quantity     int                `json:"quantity"`
+		Value        float64            `json:"value"`
+		OldestDate   time.Time          `json:"oldest_date"`
+		Buckets      map[string]int     `json:"buckets"`
+		BucketValues map[string]float64 `json:"bucket_values"`
+	}
+
+	var lines []*AgingLine
+	byProduct := map[uint]*AgingLine{}
+	totals := map[string]int{}
+	for _, row := range rows {
+		line, ok := byProduct[row.ProductID]
+		if !ok {
+			line = &AgingLine{ProductID: row.ProductID, SKU: row.SKU, ProductName: row.ProductName,
+				CategoryName: row.CategoryName, OldestDate: row.Oldest,
+				Buckets: map[string]int{}, BucketValues: map[string]float64{}}
+			byProduct[row.ProductID] = line
+			lines = append(lines, line)
+		}
+		line.Quantity += row.Quantity
+		line.Value = roundCost(line.Value + row.Value)
+		line.Buckets[row.Bucket] = row.Quantity
+		line.BucketValues[row.Bucket] = roundCost(row.Value)
+		if row.Oldest.Before(line.OldestDate) {
+			line.OldestDate = row.Oldest
+		}
+		totals[row.Bucket] += row.Quantity
+	}
+
+	// Stock sin capas: el que existía antes de registrar costos
+	var untracked int64
+	untrackedQuery := config.DB.Table("products as p").
+		Select("COALESCE(SUM(GREATEST(p.stock - COALESCE(l.remaining, 0), 0)), 0)").
+		Joins("LEFT JOIN (SELECT product_id, SUM(remaining) as remaining FROM cost_layers GROUP BY product_id) as l ON l.product_id = p.id").
+		Where("p.deleted_at IS NULL")
+	if categoryID != "" {
+		untrackedQuery = untrackedQuery.Where("p.category_id = ?", categoryID)
+	}
+	untrackedQuery.Scan(&untracked)
+
+	names := make([]string, len(agingBuckets))
+	for i, bucket := range agingBuckets {
+		names[i] = bucket.Name
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"products":        lines,
+		"total":           len(lines),
+		"buckets":         names,
+		"bucket_totals":   totals,
+		"untracked_stock": untracked,
+	})
+}
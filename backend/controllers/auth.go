@@ -1,20 +1,43 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
+	"os"
 
 	"github.com/Stormdead/inventory-control-panel/backend/config"
 	"github.com/Stormdead/inventory-control-panel/backend/models"
-	"github.com/Stormdead/inventory-control-panel/backend/utils"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type RegisterRequest struct {
 	Username string `json:"username" binding:"required,min=3,max=50"`
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=6"`
-	Role     string `json:"role"` // Opcional, por defecto "employee"
+	Role     string `json:"role"` // Solo se acepta "employee"; otros roles los asigna un admin
+}
+
+type BootstrapRequest struct {
+	Username string `json:"username" binding:"required,min=3,max=50"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+// ErrAlreadyBootstrapped indica que ya existen usuarios y no corresponde crear el admin inicial
+var ErrAlreadyBootstrapped = errors.New("El sistema ya tiene usuarios; el admin inicial solo se crea en la primera ejecución")
+
+// publicRegistrationEnabled lee PUBLIC_REGISTRATION; "false" deshabilita el registro público
+func publicRegistrationEnabled() bool {
+	return os.Getenv("PUBLIC_REGISTRATION") != "false"
+}
+
+// hashPassword encripta una contraseña con bcrypt
+func hashPassword(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hashed), err
 }
 
 type LoginRequest struct {
@@ -22,8 +45,13 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
-// POST /api/auth/register
+// POST /api/auth/register - Registro público (se deshabilita con PUBLIC_REGISTRATION=false)
 func Register(c *gin.Context) {
+	if !publicRegistrationEnabled() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "El registro público está deshabilitado; solicite una invitación"})
+		return
+	}
+
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -37,20 +65,16 @@ func Register(c *gin.Context) {
 		return
 	}
 
-	// Encriptar contraseña
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al procesar la contraseña"})
+	// El registro público siempre crea empleados; los demás roles los asigna un admin
+	if req.Role != "" && req.Role != models.RoleEmployee {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Solo un administrador puede asignar roles"})
 		return
 	}
 
-	// Validar rol
-	role := req.Role
-	if role == "" {
-		role = "employee"
-	}
-	if role != "admin" && role != "employee" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Rol inválido"})
+	// Encriptar contraseña
+	hashedPassword, err := hashPassword(req.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al procesar la contraseña"})
 		return
 	}
 
@@ -58,8 +82,8 @@ func Register(c *gin.Context) {
 	user := models.User{
 		Username: req.Username,
 		Email:    req.Email,
-		Password: string(hashedPassword),
-		Role:     role,
+		Password: hashedPassword,
+		Role:     models.RoleEmployee,
 	}
 
 	if err := config.DB.Create(&user).Error; err != nil {
@@ -67,23 +91,76 @@ func Register(c *gin.Context) {
 		return
 	}
 
-	// Generar token JWT
-	token, err := utils.GenerateToken(user.ID, user.Username, user.Role)
+	// Iniciar sesión: token de acceso y refresh token
+	tokens, err := issueTokens(config.DB, user, "")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al generar token"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "Usuario registrado exitosamente",
-		"token":   token,
-		"user": gin.H{
-			"id":       user.ID,
-			"username": user.Username,
-			"email":    user.Email,
-			"role":     user.Role,
-		},
-	})
+	c.JSON(http.StatusCreated, authResponse("Usuario registrado exitosamente", tokens, user))
+}
+
+// BootstrapAdmin crea el primer usuario como admin. Falla con ErrAlreadyBootstrapped si
+// ya hay usuarios (incluidos los eliminados), así que solo funciona en la primera ejecución.
+func BootstrapAdmin(db *gorm.DB, username, email, password string) (*models.User, error) {
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := db.Begin()
+
+	// El bloqueo impide que dos peticiones simultáneas creen cada una un admin
+	var count int64
+	if err := tx.Unscoped().Model(&models.User{}).Clauses(clause.Locking{Strength: "UPDATE"}).Count(&count).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if count > 0 {
+		tx.Rollback()
+		return nil, ErrAlreadyBootstrapped
+	}
+
+	user := models.User{
+		Username: username,
+		Email:    email,
+		Password: hashedPassword,
+		Role:     models.RoleAdmin,
+	}
+	if err := tx.Create(&user).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return &user, tx.Commit().Error
+}
+
+// POST /api/auth/bootstrap - Crear el admin inicial (solo si todavía no hay usuarios)
+func Bootstrap(c *gin.Context) {
+	var req BootstrapRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := BootstrapAdmin(config.DB, req.Username, req.Email, req.Password)
+	if errors.Is(err, ErrAlreadyBootstrapped) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear el admin inicial"})
+		return
+	}
+
+	tokens, err := issueTokens(config.DB, *user, "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al generar token"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, authResponse("Admin inicial creado exitosamente", tokens, *user))
 }
 
 // POST /api/auth/login
@@ -107,23 +184,19 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	// Generar token JWT
-	token, err := utils.GenerateToken(user.ID, user.Username, user.Role)
+	if !user.Active {
+		c.JSON(http.StatusForbidden, gin.H{"error": "La cuenta está desactivada"})
+		return
+	}
+
+	// Iniciar sesión: token de acceso y refresh token
+	tokens, err := issueTokens(config.DB, user, "")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al generar token"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Login exitoso",
-		"token":   token,
-		"user": gin.H{
-			"id":       user.ID,
-			"username": user.Username,
-			"email":    user.Email,
-			"role":     user.Role,
-		},
-	})
+	c.JSON(http.StatusOK, authResponse("Login exitoso", tokens, user))
 }
 
 // GET /api/auth/profile (ruta protegida)
@@ -137,13 +210,21 @@ func GetProfile(c *gin.Context) {
 		return
 	}
 
+	// Permisos del rol, para que el cliente muestre solo las acciones disponibles
+	permissions := []string{}
+	var role models.Role
+	if err := config.DB.Where("name = ?", user.Role).First(&role).Error; err == nil {
+		permissions = append(permissions, role.PermissionList()...)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"user": gin.H{
-			"id":         user.ID,
-			"username":   user.Username,
-			"email":      user.Email,
-			"role":       user.Role,
-			"created_at": user.CreatedAt,
+			"id":          user.ID,
+			"username":    user.Username,
+			"email":       user.Email,
+			"role":        user.Role,
+			"permissions": permissions,
+			"created_at":  user.CreatedAt,
 		},
 	})
 }
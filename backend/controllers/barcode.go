@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Stormdead/inventory-control-panel/backend/config"
+	"github.com/Stormdead/inventory-control-panel/backend/models"
+	"github.com/Stormdead/inventory-control-panel/backend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// normalizeSKU limpia el SKU; un SKU vacío se guarda como NULL
+func normalizeSKU(sku *string) *string {
+	if sku == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*sku)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
+// validateSKU verifica que el SKU no esté usado por otro producto, ni como SKU
+// ni como código de barras (la búsqueda por código no podría distinguirlos)
+func validateSKU(db *gorm.DB, sku *string, productID uint) error {
+	if sku == nil {
+		return nil
+	}
+	var count int64
+	db.Model(&models.Product{}).Unscoped().Where("sku = ? AND id <> ?", *sku, productID).Count(&count)
+	if count > 0 {
+		return fmt.Errorf("el SKU %s ya está en uso", *sku)
+	}
+	db.Model(&models.ProductBarcode{}).
+		Where("code IN ? AND product_id <> ?", utils.EquivalentCodes(*sku), productID).
+		Count(&count)
+	if count > 0 {
+		return fmt.Errorf("el SKU %s ya es el código de barras de otro producto", *sku)
+	}
+	return nil
+}
+
+// validateBarcodes normaliza los códigos, completa la simbología si falta y
+// verifica el dígito de control y que no estén repetidos ni usados como SKU de
+// otro producto. Los UPC-A se guardan en su forma EAN-13.
+func validateBarcodes(db *gorm.DB, barcodes []models.ProductBarcode, productID uint) error {
+	seen := map[string]bool{}
+	for i := range barcodes {
+		barcode := &barcodes[i]
+		barcode.ID = 0
+		barcode.Code = utils.NormalizeCode(barcode.Code)
+		if barcode.Symbology == "" {
+			barcode.Symbology = utils.DetectSymbology(barcode.Code)
+		}
+
+		if err := utils.ValidateBarcode(barcode.Code, barcode.Symbology); err != nil {
+			return fmt.Errorf("código %q: %v", barcode.Code, err)
+		}
+		if barcode.Symbology == utils.SymbologyUPCA {
+			barcode.Code = utils.CanonicalBarcode(barcode.Code)
+			barcode.Symbology = utils.SymbologyEAN13
+		}
+
+		if seen[barcode.Code] {
+			return fmt.Errorf("el código %s está repetido", barcode.Code)
+		}
+		seen[barcode.Code] = true
+
+		// Se comparan también los códigos guardados sin normalizar (UPC-A de 12 dígitos)
+		equivalents := utils.EquivalentCodes(barcode.Code)
+		var count int64
+		db.Model(&models.ProductBarcode{}).Where("code IN ?", equivalents).Count(&count)
+		if count > 0 {
+			return fmt.Errorf("el código %s ya está asignado a otro producto", barcode.Code)
+		}
+		db.Model(&models.Product{}).Unscoped().Where("sku IN ? AND id <> ?", equivalents, productID).Count(&count)
+		if count > 0 {
+			return fmt.Errorf("el código %s ya es el SKU de otro producto", barcode.Code)
+		}
+	}
+	return nil
+}
+
+// resolveProductCode busca un producto por SKU o por cualquiera de sus códigos de barras.
+// Un UPC-A y su forma EAN-13 (con 0 inicial) encuentran el mismo producto.
+func resolveProductCode(db *gorm.DB, code string) (*models.Product, error) {
+	code = utils.NormalizeCode(code)
+	if code == "" {
+		return nil, errProductNotFound
+	}
+
+	var product models.Product
+	err := db.Where("sku = ?", code).
+		Or("id IN (?)", db.Model(&models.ProductBarcode{}).Select("product_id").Where("code IN ?", utils.EquivalentCodes(code))).
+		First(&product).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// GET /api/products/lookup?code= - Buscar un producto por SKU o código de barras
+func LookupProduct(c *gin.Context) {
+	code := c.Query("code")
+	if strings.TrimSpace(code) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El parámetro code es requerido"})
+		return
+	}
+
+	product, err := resolveProductCode(config.DB, code)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+		return
+	}
+
+	config.DB.Preload("Category").Preload("Barcodes").Preload("Stocks.Location").First(product, product.ID)
+	fillOrderQuantities(product)
+
+	c.JSON(http.StatusOK, gin.H{
+		"product": product,
+	})
+}
+
+// POST /api/products/:id/barcodes - Agregar un código de barras a un producto (solo admin)
+func AddProductBarcode(c *gin.Context) {
+	var product models.Product
+	if err := config.DB.First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+		return
+	}
+
+	var barcode models.ProductBarcode
+	if err := c.ShouldBindJSON(&barcode); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	barcodes := []models.ProductBarcode{barcode}
+	if err := validateBarcodes(config.DB, barcodes, product.ID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	barcode = barcodes[0]
+	barcode.ProductID = product.ID
+
+	if err := config.DB.Create(&barcode).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al agregar código de barras"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Código de barras agregado exitosamente",
+		"barcode": barcode,
+	})
+}
+
+// DELETE /api/products/:id/barcodes/:barcode_id - Quitar un código de barras (solo admin)
+func DeleteProductBarcode(c *gin.Context) {
+	var barcode models.ProductBarcode
+	if err := config.DB.Where("id = ? AND product_id = ?", c.Param("barcode_id"), c.Param("id")).First(&barcode).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Código de barras no encontrado"})
+		return
+	}
+
+	if err := config.DB.Delete(&barcode).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar código de barras"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Código de barras eliminado exitosamente",
+	})
+}
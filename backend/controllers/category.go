@@ -2,21 +2,48 @@ package controllers
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/Stormdead/inventory-control-panel/backend/config"
 	"github.com/Stormdead/inventory-control-panel/backend/models"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-// GET /api/categories - listar todas las categorías
+// GET /api/categories - listar categorías con paginación por cursor (?search=, ?sort=name)
 func GetCategories(c *gin.Context) {
+	q, err := parseListQuery(c, categorySorts, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := config.DB.Model(&models.Category{})
+	if search := strings.TrimSpace(c.Query("search")); search != "" {
+		query = query.Where("categories.name LIKE ?", "%"+search+"%")
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener categorias"})
+		return
+	}
+
 	var categories []models.Category
 
-	if err := config.DB.Find(&categories).Error; err != nil {
+	if err := q.apply(query, "categories.id").Find(&categories).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener categorias"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"categories": categories})
+
+	categories, nextCursor := paginate(categories, q, categorySortValue)
+
+	c.JSON(http.StatusOK, gin.H{
+		"categories":  categories,
+		"total":       total,
+		"next_cursor": nextCursor,
+	})
 }
 
 // GET /api/categories/:id - Obtener una categoría por ID
@@ -49,6 +76,11 @@ func CreateCategory(c *gin.Context) {
 		return
 	}
 
+	if err := validateStockLevels(category.DefaultMinStock, category.DefaultReorderPoint, category.DefaultMaxStock); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	//Crear categoria
 	if err := config.DB.Create(&category).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear categoria"})
@@ -83,6 +115,19 @@ func UpdateCategory(c *gin.Context) {
 		category.Name = updateData.Name
 	}
 	category.Description = updateData.Description
+	if updateData.DefaultMinStock != nil {
+		category.DefaultMinStock = updateData.DefaultMinStock
+	}
+	if updateData.DefaultMaxStock != nil {
+		category.DefaultMaxStock = updateData.DefaultMaxStock
+	}
+	if updateData.DefaultReorderPoint != nil {
+		category.DefaultReorderPoint = updateData.DefaultReorderPoint
+	}
+	if err := validateStockLevels(category.DefaultMinStock, category.DefaultReorderPoint, category.DefaultMaxStock); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	if err := config.DB.Save(&category).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar la categoria"})
@@ -0,0 +1,370 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Stormdead/inventory-control-panel/backend/config"
+	"github.com/Stormdead/inventory-control-panel/backend/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OpenCountRequest struct {
+	LocationID  *uint  `json:"location_id"` // Por defecto la ubicación principal
+	CategoryID  *uint  `json:"category_id"`
+	Description string `json:"description"`
+}
+
+type CountEntriesRequest struct {
+	Items []struct {
+		ProductID       uint `json:"product_id" binding:"required"`
+		CountedQuantity *int `json:"counted_quantity" binding:"required,gte=0"`
+	} `json:"items" binding:"required,min=1,dive"`
+}
+
+// loadCountSession carga una sesión de conteo con sus líneas para la respuesta
+func loadCountSession(session *models.CountSession) error {
+	return config.DB.Preload("Location").Preload("Category").
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("product_id") }).
+		Preload("Lines.Product").
+		First(session, session.ID).Error
+}
+
+// GET /api/counts - Listar sesiones de conteo (filtro opcional ?status=)
+func GetCountSessions(c *gin.Context) {
+	var sessions []models.CountSession
+
+	query := config.DB.Preload("Location").Preload("Category")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Order("opened_at DESC").Find(&sessions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener conteos"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"counts": sessions,
+		"total":  len(sessions),
+	})
+}
+
+// GET /api/counts/:id - Obtener una sesión con sus líneas y diferencias
+func GetCountSession(c *gin.Context) {
+	var session models.CountSession
+
+	if err := config.DB.First(&session, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conteo no encontrado"})
+		return
+	}
+
+	loadCountSession(&session)
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": session,
+	})
+}
+
+// POST /api/counts - Abrir una sesión de conteo congelando el stock esperado (solo admin)
+func OpenCountSession(c *gin.Context) {
+	var req OpenCountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	if req.LocationID == nil {
+		locationID, err := defaultLocationID(config.DB)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No hay ubicación por defecto"})
+			return
+		}
+		req.LocationID = &locationID
+	} else {
+		var location models.Location
+		if err := config.DB.First(&location, *req.LocationID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "La ubicación especificada no existe"})
+			return
+		}
+	}
+
+	if req.CategoryID != nil {
+		var category models.Category
+		if err := config.DB.First(&category, *req.CategoryID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "La categoría especificada no existe"})
+			return
+		}
+	}
+
+	session := models.CountSession{
+		LocationID:  *req.LocationID,
+		CategoryID:  req.CategoryID,
+		Status:      models.CountStatusAbierta,
+		Description: req.Description,
+		OpenedByID:  userID.(uint),
+		OpenedAt:    time.Now(),
+	}
+
+	tx := config.DB.Begin()
+
+	if err := tx.Create(&session).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al abrir conteo"})
+		return
+	}
+
+	// Congelar el stock esperado de cada producto en la ubicación
+	products := tx.Table("products as p").
+		Select("p.id as product_id, COALESCE(ps.quantity, 0) as expected_quantity").
+		Joins("LEFT JOIN product_stocks ps ON ps.product_id = p.id AND ps.location_id = ?", session.LocationID).
+		Where("p.deleted_at IS NULL")
+	if session.CategoryID != nil {
+		products = products.Where("p.category_id = ?", *session.CategoryID)
+	}
+
+	var lines []models.CountLine
+	if err := products.Order("p.id").Scan(&lines).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al abrir conteo"})
+		return
+	}
+
+	if len(lines) == 0 {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No hay productos para contar"})
+		return
+	}
+
+	for i := range lines {
+		lines[i].SessionID = session.ID
+	}
+	if err := tx.CreateInBatches(&lines, 500).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al abrir conteo"})
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al abrir conteo"})
+		return
+	}
+
+	loadCountSession(&session)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Conteo abierto exitosamente",
+		"count":   session,
+	})
+}
+
+// POST /api/counts/:id/entries - Registrar cantidades contadas
+func SubmitCountEntries(c *gin.Context) {
+	var req CountEntriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+	uid := userID.(uint)
+
+	tx := config.DB.Begin()
+
+	// Bloquear la sesión para que no se apruebe ni cancele mientras se registra el conteo
+	var session models.CountSession
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&session, c.Param("id")).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conteo no encontrado"})
+		return
+	}
+
+	if session.Status != models.CountStatusAbierta {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": "El conteo ya está cerrado"})
+		return
+	}
+
+	now := time.Now()
+	for _, item := range req.Items {
+		result := tx.Model(&models.CountLine{}).
+			Where("session_id = ? AND product_id = ?", session.ID, item.ProductID).
+			Updates(map[string]interface{}{
+				"counted_quantity": *item.CountedQuantity,
+				"counted_by_id":    uid,
+				"counted_at":       now,
+			})
+		if result.Error != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al registrar el conteo"})
+			return
+		}
+		if result.RowsAffected == 0 {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("El producto %d no forma parte de este conteo", item.ProductID)})
+			return
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al registrar el conteo"})
+		return
+	}
+
+	loadCountSession(&session)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Conteo registrado exitosamente",
+		"count":   session,
+	})
+}
+
+// POST /api/counts/:id/approve - Aprobar el conteo y registrar los ajustes (solo admin).
+// Cada ajuste es la diferencia entre lo contado y lo esperado al abrir la sesión, aplicada
+// sobre el stock actual: así los movimientos hechos durante el conteo se conservan.
+func ApproveCountSession(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+	uid := userID.(uint)
+
+	tx := config.DB.Begin()
+
+	// Bloquear la sesión y leer las líneas con bloqueo para usar lo último registrado
+	var session models.CountSession
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Lines", func(db *gorm.DB) *gorm.DB {
+			return db.Clauses(clause.Locking{Strength: "UPDATE"})
+		}).
+		First(&session, c.Param("id")).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conteo no encontrado"})
+		return
+	}
+
+	if session.Status != models.CountStatusAbierta {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": "El conteo ya está cerrado"})
+		return
+	}
+
+	reasonCodeID := systemReasonCodeID(tx, models.ReasonCodeConteo)
+	adjustments, uncounted := 0, 0
+
+	for _, line := range session.Lines {
+		variance := line.Variance()
+		if variance == nil {
+			uncounted++
+			continue
+		}
+		if *variance == 0 {
+			continue
+		}
+
+		adjustment := models.Movement{
+			ProductID:    line.ProductID,
+			UserID:       uid,
+			LocationID:   &session.LocationID,
+			Type:         models.MovementTypeAjusteEntrada,
+			Quantity:     *variance,
+			ReasonCodeID: reasonCodeID,
+			Description: fmt.Sprintf("Conteo #%d: esperado %d, contado %d",
+				session.ID, line.ExpectedQuantity, *line.CountedQuantity),
+			MovementDate: time.Now(),
+		}
+		if *variance < 0 {
+			adjustment.Type = models.MovementTypeAjusteSalida
+			adjustment.Quantity = -*variance
+		}
+
+		if _, err := applyMovement(tx, &adjustment); err != nil {
+			tx.Rollback()
+			var stockErr *insufficientStockError
+			if errors.As(err, &stockErr) {
+				c.JSON(http.StatusConflict, gin.H{
+					"error":        "El ajuste dejaría el stock en negativo; revise los movimientos hechos durante el conteo",
+					"product_id":   line.ProductID,
+					"stock_actual": stockErr.Stock,
+				})
+				return
+			}
+			respondMovementError(c, err)
+			return
+		}
+
+		if err := tx.Model(&line).Update("adjustment_movement_id", adjustment.ID).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al aprobar conteo"})
+			return
+		}
+		adjustments++
+	}
+
+	now := time.Now()
+	if err := tx.Model(&session).Updates(map[string]interface{}{
+		"status":         models.CountStatusAprobada,
+		"approved_by_id": uid,
+		"closed_at":      now,
+	}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al aprobar conteo"})
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al aprobar conteo"})
+		return
+	}
+
+	loadCountSession(&session)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":            "Conteo aprobado exitosamente",
+		"count":              session,
+		"adjustments":        adjustments,
+		"uncounted_products": uncounted,
+	})
+}
+
+// POST /api/counts/:id/cancel - Cancelar un conteo abierto sin ajustar stock (solo admin)
+func CancelCountSession(c *gin.Context) {
+	var session models.CountSession
+
+	if err := config.DB.First(&session, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conteo no encontrado"})
+		return
+	}
+
+	now := time.Now()
+	result := config.DB.Model(&session).
+		Where("status = ?", models.CountStatusAbierta).
+		Updates(map[string]interface{}{"status": models.CountStatusCancelada, "closed_at": now})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al cancelar conteo"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "El conteo ya está cerrado"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Conteo cancelado exitosamente",
+	})
+}
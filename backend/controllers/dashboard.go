@@ -1,48 +1,80 @@
 package controllers
 
 import (
+	"errors"
+	"math"
 	"net/http"
 	"time"
 
 	"github.com/Stormdead/inventory-control-panel/backend/config"
 	"github.com/Stormdead/inventory-control-panel/backend/models"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+type dashboardStats struct {
+	TotalProducts    int64   `json:"total_products"`
+	TotalCategories  int64   `json:"total_categories"`
+	TotalStock       int     `json:"total_stock"`
+	LowStockProducts int64   `json:"low_stock_products"`
+	TotalUsers       int64   `json:"total_users"`
+	TotalValue       float64 `json:"total_inventory_value"` // Valor a costo promedio
+	RetailValue      float64 `json:"total_retail_value"`    // Valor a precio de venta
+}
+
 // GET /api/dashboard/stats - Estadísticas generales del inventario
 func GetDashboardStats(c *gin.Context) {
-	var stats struct {
-		TotalProducts    int64   `json:"total_products"`
-		TotalCategories  int64   `json:"total_categories"`
-		TotalStock       int     `json:"total_stock"`
-		LowStockProducts int64   `json:"low_stock_products"`
-		TotalUsers       int64   `json:"total_users"`
-		TotalValue       float64 `json:"total_inventory_value"`
-	}
+	c.JSON(http.StatusOK, gin.H{
+		"stats": computeDashboardStats(c.Query("location_id")),
+	})
+}
+
+// computeDashboardStats calcula las estadísticas generales, opcionalmente para una sola ubicación
+func computeDashboardStats(locationID string) dashboardStats {
+	var stats dashboardStats
 
-	config.DB.Model(&models.Product{}).Count(&stats.TotalProducts)
 	config.DB.Model(&models.Category{}).Count(&stats.TotalCategories)
 	config.DB.Model(&models.User{}).Count(&stats.TotalUsers)
-	config.DB.Model(&models.Product{}).Where("stock < ?", 10).Count(&stats.LowStockProducts)
-
-	var products []models.Product
-	config.DB.Find(&products)
 
-	for _, product := range products {
-		stats.TotalStock += product.Stock
-		stats.TotalValue += float64(product.Stock) * product.Price
+	// Con location_id las cifras de stock se calculan solo para esa ubicación
+	var totals struct {
+		TotalStock  int
+		TotalValue  float64
+		RetailValue float64
 	}
+	if locationID != "" {
+		locationStocks := func() *gorm.DB {
+			return config.DB.Table("product_stocks as ps").
+				Joins("JOIN products p ON p.id = ps.product_id AND p.deleted_at IS NULL").
+				Where("ps.location_id = ? AND ps.quantity <> 0", locationID)
+		}
+		locationStocks().Count(&stats.TotalProducts)
+		locationStocks().Where("ps.quantity < " + reorderPointSQL("p")).Count(&stats.LowStockProducts)
+		locationStocks().Select("COALESCE(SUM(ps.quantity), 0) as total_stock, COALESCE(SUM(ps.quantity * p.average_cost), 0) as total_value, " +
+			"COALESCE(SUM(ps.quantity * p.price), 0) as retail_value").Scan(&totals)
+	} else {
+		config.DB.Model(&models.Product{}).Count(&stats.TotalProducts)
+		config.DB.Model(&models.Product{}).Where("products.stock < " + reorderPointSQL("products")).Count(&stats.LowStockProducts)
+		config.DB.Model(&models.Product{}).Select("COALESCE(SUM(stock), 0) as total_stock, COALESCE(SUM(stock * average_cost), 0) as total_value, " +
+			"COALESCE(SUM(stock * price), 0) as retail_value").Scan(&totals)
+	}
+	stats.TotalStock = totals.TotalStock
+	stats.TotalValue = totals.TotalValue
+	stats.RetailValue = totals.RetailValue
 
-	c.JSON(http.StatusOK, gin.H{
-		"stats": stats,
-	})
+	return stats
 }
 
 // GET /api/dashboard/recent-movements - Movimientos recientes (últimos 10)
 func GetRecentMovements(c *gin.Context) {
 	var movements []models.Movement
 
-	if err := config.DB.Preload("Product").Preload("Product.Category").Preload("User").
+	query := config.DB.Preload("Product").Preload("Product.Category").Preload("User", unscoped).Preload("Location")
+	if locationID := c.Query("location_id"); locationID != "" {
+		query = query.Where("location_id = ?", locationID)
+	}
+
+	if err := query.
 		Order("movement_date DESC").
 		Limit(10).
 		Find(&movements).Error; err != nil {
@@ -60,10 +92,14 @@ func GetRecentMovements(c *gin.Context) {
 func GetLowStockAlerts(c *gin.Context) {
 	var products []models.Product
 
-	if err := config.DB.Preload("Category").
-		Where("stock < ?", 10).
-		Order("stock ASC").
-		Find(&products).Error; err != nil {
+	locationID := c.Query("location_id")
+	query := config.DB.Preload("Category").Scopes(lowStockScope(locationID))
+	if locationID != "" {
+		// Se incluye el stock de esa ubicación en la respuesta
+		query = query.Preload("Stocks", "location_id = ?", locationID)
+	}
+
+	if err := query.Find(&products).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener productos"})
 		return
 	}
@@ -75,62 +111,313 @@ func GetLowStockAlerts(c *gin.Context) {
 	})
 }
 
-// GET /api/dashboard/movement-summary - Resumen de movimientos (último mes)
+// lowStockScope filtra los productos por debajo de su punto de pedido, en total o en una ubicación,
+// ordenados de menor a mayor stock. Espera una consulta sobre la tabla products.
+func lowStockScope(locationID string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if locationID != "" {
+			return db.Joins("JOIN product_stocks ps ON ps.product_id = products.id AND ps.location_id = ?", locationID).
+				Where("ps.quantity < " + reorderPointSQL("products")).
+				Order("ps.quantity ASC")
+		}
+		return db.Where("products.stock < " + reorderPointSQL("products")).Order("products.stock ASC")
+	}
+}
+
+// movementSummary son los totales clásicos del resumen: entradas y salidas comerciales
+type movementSummary struct {
+	TotalEntradas    int64 `json:"total_entradas"`
+	TotalSalidas     int64 `json:"total_salidas"`
+	CantidadEntradas int   `json:"cantidad_entradas"`
+	CantidadSalidas  int   `json:"cantidad_salidas"`
+}
+
+// movementTypeTotal es la cantidad de movimientos y unidades de un tipo
+type movementTypeTotal struct {
+	Type      string `json:"type"`
+	Movements int64  `json:"movements"`
+	Quantity  int64  `json:"quantity"`
+}
+
+// summaryGroupings son las agrupaciones de la serie temporal: cada expresión devuelve
+// la fecha de inicio del día, la semana (lunes) o el mes del movimiento
+var summaryGroupings = map[string]string{
+	"day":   "DATE_FORMAT(m.movement_date, '%Y-%m-%d')",
+	"week":  "DATE_FORMAT(DATE_SUB(DATE(m.movement_date), INTERVAL WEEKDAY(m.movement_date) DAY), '%Y-%m-%d')",
+	"month": "DATE_FORMAT(m.movement_date, '%Y-%m-01')",
+}
+
+// periodStart devuelve el inicio del día, la semana (lunes) o el mes de t, como summaryGroupings
+func periodStart(t time.Time, groupBy string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch groupBy {
+	case "week":
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case "month":
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day
+}
+
+// nextPeriod devuelve el inicio del período siguiente a start
+func nextPeriod(start time.Time, groupBy string) time.Time {
+	switch groupBy {
+	case "week":
+		return start.AddDate(0, 0, 7)
+	case "month":
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// summarizeMovements calcula los totales por tipo de un período y los totales clásicos
+func summarizeMovements(locationID string, from, to time.Time) (movementSummary, []movementTypeTotal, error) {
+	var summary movementSummary
+	byType := []movementTypeTotal{}
+	if err := summaryMovements(locationID, from, to).
+		Select("m.type, COUNT(*) as movements, COALESCE(SUM(m.quantity), 0) as quantity").
+		Group("m.type").
+		Order("m.type").
+		Scan(&byType).Error; err != nil {
+		return summary, nil, err
+	}
+
+	// Los totales clásicos solo cuentan entradas y salidas comerciales
+	for _, total := range byType {
+		switch total.Type {
+		case models.MovementTypeEntrada:
+			summary.TotalEntradas = total.Movements
+			summary.CantidadEntradas = int(total.Quantity)
+		case models.MovementTypeSalida:
+			summary.TotalSalidas = total.Movements
+			summary.CantidadSalidas = int(total.Quantity)
+		}
+	}
+	return summary, byType, nil
+}
+
+// percentChange devuelve la variación porcentual de previous a current; nil si previous es 0
+func percentChange(current, previous int64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	change := math.Round(float64(current-previous)/float64(previous)*10000) / 100
+	return &change
+}
+
+// GET /api/dashboard/movement-summary - Resumen de movimientos con desglose por tipo y motivo,
+// serie temporal y comparación con el período anterior de igual duración
+// (?from=&to= por defecto los últimos 30 días, ?group_by=day|week|month, ?location_id=)
 func GetMovementSummary(c *gin.Context) {
-	thirtyDaysAgo := time.Now().AddDate(0, 0, -30)
+	from, to, err := parsePeriod(c, 30)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	groupBy := c.DefaultQuery("group_by", "day")
+	grouping, ok := summaryGroupings[groupBy]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "group_by inválido; use day, week o month"})
+		return
+	}
+
+	type ReasonTotal struct {
+		Type       string `json:"type"`
+		ReasonCode string `json:"reason_code"`
+		ReasonName string `json:"reason_name"`
+		Movements  int64  `json:"movements"`
+		Quantity   int64  `json:"quantity"`
+	}
+
+	type SeriesPoint struct {
+		Period    string `json:"period"`
+		Movements int64  `json:"movements"`
+		Inbound   int64  `json:"inbound"`  // Unidades que ingresaron
+		Outbound  int64  `json:"outbound"` // Unidades que salieron
+		Net       int64  `json:"net"`
+	}
+
+	locationID := c.Query("location_id")
+
+	summary, byType, err := summarizeMovements(locationID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener resumen"})
+		return
+	}
 
-	var summary struct {
-		TotalEntradas    int64 `json:"total_entradas"`
-		TotalSalidas     int64 `json:"total_salidas"`
-		CantidadEntradas int   `json:"cantidad_entradas"`
-		CantidadSalidas  int   `json:"cantidad_salidas"`
+	var byReason []ReasonTotal
+	if err := summaryMovements(locationID, from, to).
+		Select("m.type, r.code as reason_code, r.name as reason_name, COUNT(*) as movements, COALESCE(SUM(m.quantity), 0) as quantity").
+		Joins("JOIN reason_codes r ON r.id = m.reason_code_id").
+		Group("m.type, r.code, r.name").
+		Order("m.type, r.code").
+		Scan(&byReason).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener resumen"})
+		return
 	}
 
-	config.DB.Model(&models.Movement{}).
-		Where("type = ? AND movement_date >= ?", "entrada", thirtyDaysAgo).
-		Count(&summary.TotalEntradas)
+	var points []SeriesPoint
+	if err := movementSeries(locationID, from, to, grouping).Scan(&points).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener resumen"})
+		return
+	}
 
-	config.DB.Model(&models.Movement{}).
-		Where("type = ? AND movement_date >= ?", "salida", thirtyDaysAgo).
-		Count(&summary.TotalSalidas)
+	// Completar con ceros los períodos sin movimientos para graficar una serie continua
+	byPeriod := map[string]SeriesPoint{}
+	for _, point := range points {
+		byPeriod[point.Period] = point
+	}
+	series := []SeriesPoint{}
+	for start := periodStart(from, groupBy); !start.After(to); start = nextPeriod(start, groupBy) {
+		key := start.Format("2006-01-02")
+		point, ok := byPeriod[key]
+		if !ok {
+			point = SeriesPoint{Period: key}
+		}
+		series = append(series, point)
+	}
 
-	var entradas []models.Movement
-	config.DB.Where("type = ? AND movement_date >= ?", "entrada", thirtyDaysAgo).Find(&entradas)
-	for _, mov := range entradas {
-		summary.CantidadEntradas += mov.Quantity
+	// Período anterior: la misma duración, terminando donde empieza el actual
+	previousTo := from.Add(-time.Nanosecond)
+	previousFrom := from.Add(-to.Sub(from))
+	previous, previousByType, err := summarizeMovements(locationID, previousFrom, previousTo)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener resumen"})
+		return
 	}
 
-	var salidas []models.Movement
-	config.DB.Where("type = ? AND movement_date >= ?", "salida", thirtyDaysAgo).Find(&salidas)
-	for _, mov := range salidas {
-		summary.CantidadSalidas += mov.Quantity
+	period := "Últimos 30 días"
+	if c.Query("from") != "" || c.Query("to") != "" {
+		period = from.Format("2006-01-02") + " a " + to.Format("2006-01-02")
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"summary": summary,
-		"period":  "Últimos 30 días",
+		"summary":   summary,
+		"by_type":   byType,
+		"by_reason": byReason,
+		"series":    series,
+		"group_by":  groupBy,
+		"from":      from,
+		"to":        to,
+		"period":    period,
+		"comparison": gin.H{
+			"from":    previousFrom,
+			"to":      previousTo,
+			"summary": previous,
+			"by_type": previousByType,
+			"change": gin.H{
+				"total_entradas":    percentChange(summary.TotalEntradas, previous.TotalEntradas),
+				"total_salidas":     percentChange(summary.TotalSalidas, previous.TotalSalidas),
+				"cantidad_entradas": percentChange(int64(summary.CantidadEntradas), int64(previous.CantidadEntradas)),
+				"cantidad_salidas":  percentChange(int64(summary.CantidadSalidas), int64(previous.CantidadSalidas)),
+			},
+		},
 	})
 }
 
-// GET /api/dashboard/top-products - Top 5 productos con más movimientos
+// movementSeries agrupa los movimientos del período con grouping (ver summaryGroupings):
+// cantidad de movimientos y unidades ingresadas, egresadas y netas de cada período
+func movementSeries(locationID string, from, to time.Time, grouping string) *gorm.DB {
+	delta := models.StockDeltaSQL("m")
+	return summaryMovements(locationID, from, to).
+		Select(grouping + " as period, COUNT(*) as movements, " +
+			"COALESCE(SUM(GREATEST(" + delta + ", 0)), 0) as inbound, " +
+			"COALESCE(SUM(GREATEST(-(" + delta + "), 0)), 0) as outbound, " +
+			"COALESCE(SUM(" + delta + "), 0) as net").
+		Group("period").
+		Order("period")
+}
+
+// summaryMovements devuelve los movimientos (alias m) entre dos fechas, opcionalmente de una ubicación
+func summaryMovements(locationID string, from, to time.Time) *gorm.DB {
+	query := config.DB.Table("movements as m").Where("m.movement_date BETWEEN ? AND ?", from, to)
+	if locationID != "" {
+		query = query.Where("m.location_id = ?", locationID)
+	}
+	return query
+}
+
+// GET /api/dashboard/top-products - Top 5 productos con más movimientos (?type= para un solo tipo)
 func GetTopProducts(c *gin.Context) {
 	type ProductMovement struct {
-		ProductID      uint   `json:"product_id"`
-		ProductName    string `json:"product_name"`
-		TotalMovements int64  `json:"total_movements"`
-		CurrentStock   int    `json:"current_stock"`
-		CategoryName   string `json:"category_name"`
+		ProductID      uint             `json:"product_id"`
+		ProductName    string           `json:"product_name"`
+		TotalMovements int64            `json:"total_movements"`
+		CurrentStock   int              `json:"current_stock"`
+		CategoryName   string           `json:"category_name"`
+		ByType         map[string]int64 `json:"by_type" gorm:"-"`
 	}
 
 	var results []ProductMovement
 
+	query, err := topProductsQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := query.Scan(&results).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener estadísticas"})
+		return
+	}
+
+	// Desglose por tipo para distinguir ventas de bajas, ajustes y transferencias
+	if len(results) > 0 {
+		productIDs := make([]uint, len(results))
+		for i, r := range results {
+			productIDs[i] = r.ProductID
+		}
+
+		var counts []struct {
+			ProductID uint
+			Type      string
+			Total     int64
+		}
+		breakdown := config.DB.Model(&models.Movement{}).
+			Select("product_id, type, COUNT(*) as total").
+			Where("product_id IN ?", productIDs).
+			Group("product_id, type")
+		if locationID := c.Query("location_id"); locationID != "" {
+			breakdown = breakdown.Where("location_id = ?", locationID)
+		}
+		breakdown.Scan(&counts)
+
+		for i := range results {
+			results[i].ByType = map[string]int64{}
+			for _, count := range counts {
+				if count.ProductID == results[i].ProductID {
+					results[i].ByType[count.Type] = count.Total
+				}
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"products": results,
+		"total":    len(results),
+	})
+}
+
+// topProductsQuery arma la consulta del top 5 de productos con más movimientos,
+// filtrada por location_id y type
+func topProductsQuery(c *gin.Context) (*gorm.DB, error) {
 	// Subconsulta para contar movimientos
 	subQuery := config.DB.Model(&models.Movement{}).
 		Select("product_id, COUNT(*) as movement_count").
 		Group("product_id")
+	if locationID := c.Query("location_id"); locationID != "" {
+		subQuery = subQuery.Where("location_id = ?", locationID)
+	}
+	if movementType := c.Query("type"); movementType != "" {
+		if models.MovementSign(movementType) == 0 {
+			return nil, errors.New("Tipo de movimiento inválido")
+		}
+		subQuery = subQuery.Where("type = ?", movementType)
+	}
 
 	// Query principal con joins elegantes
-	err := config.DB.Table("products as p").
+	return config.DB.Table("products as p").
 		Select(`
 			p.id as product_id,
 			p.name as product_name,
@@ -142,16 +429,5 @@ func GetTopProducts(c *gin.Context) {
 		Joins("LEFT JOIN categories c ON p.category_id = c.id").
 		Where("p.deleted_at IS NULL").
 		Order("total_movements DESC").
-		Limit(5).
-		Scan(&results).Error
-
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener estadísticas"})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"products": results,
-		"total":    len(results),
-	})
+		Limit(5), nil
 }
@@ -0,0 +1,328 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Stormdead/inventory-control-panel/backend/config"
+	"github.com/Stormdead/inventory-control-panel/backend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Cada cuántas filas se envía lo escrito al cliente
+const exportFlushEvery = 500
+
+// exportFormat lee ?format=csv|xlsx (csv por defecto)
+func exportFormat(c *gin.Context) (string, bool) {
+	format := c.DefaultQuery("format", utils.ExportCSV)
+	if format != utils.ExportCSV && format != utils.ExportXLSX {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Formato inválido; use csv o xlsx"})
+		return "", false
+	}
+	return format, true
+}
+
+// streamExport ejecuta la consulta y escribe cada fila a medida que se lee, sin
+// cargar el resultado completo en memoria. row convierte cada registro en las celdas de la fila.
+func streamExport[T any](c *gin.Context, format, name string, query *gorm.DB, header []interface{}, row func(*T) []interface{}) {
+	rows, err := query.Rows()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al generar la exportación"})
+		return
+	}
+	defer rows.Close()
+
+	writer, ok := startExport(c, format, name)
+	if !ok {
+		return
+	}
+
+	writer.WriteRow(header)
+	count := 0
+	for rows.Next() {
+		var item T
+		if err := config.DB.ScanRows(rows, &item); err != nil {
+			// Los encabezados ya se enviaron; solo queda cortar la descarga
+			log.Printf("Error en exportación %s: %v", name, err)
+			return
+		}
+		if err := writer.WriteRow(row(&item)); err != nil {
+			log.Printf("Error en exportación %s: %v", name, err)
+			return
+		}
+
+		count++
+		if count%exportFlushEvery == 0 {
+			writer.Flush()
+			c.Writer.Flush()
+		}
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error en exportación %s: %v", name, err)
+		return
+	}
+
+	finishExport(c, writer, name)
+}
+
+// startExport envía los encabezados de la descarga y crea el escritor del formato
+func startExport(c *gin.Context, format, name string) (utils.RowWriter, bool) {
+	filename := fmt.Sprintf("%s_%s.%s", name, time.Now().Format("20060102_150405"), format)
+	c.Header("Content-Type", utils.ExportContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+
+	writer, err := utils.NewRowWriter(format, c.Writer)
+	if err != nil {
+		log.Printf("Error en exportación %s: %v", name, err)
+		return nil, false
+	}
+	return writer, true
+}
+
+func finishExport(c *gin.Context, writer utils.RowWriter, name string) {
+	if err := writer.Close(); err != nil {
+		log.Printf("Error en exportación %s: %v", name, err)
+		return
+	}
+	c.Writer.Flush()
+}
+
+// GET /api/products/export - Exportar productos a CSV/XLSX (mismos filtros y orden que el listado)
+func ExportProducts(c *gin.Context) {
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+
+	q, err := parseListQuery(c, productSorts, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query, err := filterProducts(c, config.DB.Table("products").Where("products.deleted_at IS NULL"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	type productRow struct {
+		ID           uint
+		SKU          *string
+		Name         string
+		Description  string
+		CategoryName *string
+		Price        float64
+		Stock        int
+		CreatedAt    time.Time
+		UpdatedAt    time.Time
+	}
+
+	query = query.
+		Select(`products.id, products.sku, products.name, products.description, categories.name as category_name,
+			products.price, products.stock, products.created_at, products.updated_at`).
+		Joins("LEFT JOIN categories ON categories.id = products.category_id")
+
+	header := []interface{}{"id", "sku", "nombre", "descripcion", "categoria", "precio", "stock", "creado", "actualizado"}
+	streamExport(c, format, "productos", q.orderAll(query, "products.id"), header, func(p *productRow) []interface{} {
+		return []interface{}{p.ID, p.SKU, p.Name, p.Description, p.CategoryName, p.Price, p.Stock, p.CreatedAt, p.UpdatedAt}
+	})
+}
+
+// movementRow es una fila de la exportación de movimientos con las relaciones aplanadas
+type movementRow struct {
+	ID           uint
+	MovementDate time.Time
+	Type         string
+	ProductID    uint
+	ProductSKU   *string
+	ProductName  *string
+	CategoryName *string
+	Quantity     int
+	LocationName *string
+	ReasonCode   *string
+	Username     *string
+	Description  string
+	ReversalOfID *uint
+	TransferID   *uint
+}
+
+var movementExportHeader = []interface{}{"id", "fecha", "tipo", "producto_id", "sku", "producto", "categoria",
+	"cantidad", "ubicacion", "motivo", "usuario", "descripcion", "reverso_de", "transferencia"}
+
+func (m *movementRow) cells() []interface{} {
+	return []interface{}{m.ID, m.MovementDate, m.Type, m.ProductID, m.ProductSKU, m.ProductName, m.CategoryName,
+		m.Quantity, m.LocationName, m.ReasonCode, m.Username, m.Description, m.ReversalOfID, m.TransferID}
+}
+
+// movementExportQuery arma la consulta de movimientos con las relaciones aplanadas.
+// Los productos eliminados se incluyen para no perder el historial.
+func movementExportQuery(db *gorm.DB) *gorm.DB {
+	return db.
+		Select(`movements.id, movements.movement_date, movements.type, movements.product_id,
+			products.sku as product_sku, products.name as product_name, categories.name as category_name,
+			movements.quantity, locations.name as location_name, reason_codes.code as reason_code,
+			users.username, movements.description, movements.reversal_of_id, movements.transfer_id`).
+		Joins("LEFT JOIN products ON products.id = movements.product_id").
+		Joins("LEFT JOIN categories ON categories.id = products.category_id").
+		Joins("LEFT JOIN locations ON locations.id = movements.location_id").
+		Joins("LEFT JOIN reason_codes ON reason_codes.id = movements.reason_code_id").
+		Joins("LEFT JOIN users ON users.id = movements.user_id")
+}
+
+// GET /api/movements/export - Exportar movimientos a CSV/XLSX (mismos filtros y orden que el listado)
+func ExportMovements(c *gin.Context) {
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+
+	q, err := parseListQuery(c, movementSorts, "-movement_date")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query, err := filterMovements(c, config.DB.Table("movements"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	streamExport(c, format, "movimientos", q.orderAll(movementExportQuery(query), "movements.id"),
+		movementExportHeader, (*movementRow).cells)
+}
+
+// GET /api/dashboard/export/:report - Exportar un reporte del dashboard a CSV/XLSX.
+// report: stats, recent-movements, low-stock-alerts, movement-summary (?from=&to=&group_by=) o top-products
+func ExportDashboardReport(c *gin.Context) {
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+	locationID := c.Query("location_id")
+
+	switch c.Param("report") {
+	case "stats":
+		stats := computeDashboardStats(locationID)
+		writer, ok := startExport(c, format, "estadisticas")
+		if !ok {
+			return
+		}
+		rows := [][]interface{}{
+			{"indicador", "valor"},
+			{"total_productos", stats.TotalProducts},
+			{"total_categorias", stats.TotalCategories},
+			{"stock_total", stats.TotalStock},
+			{"productos_stock_bajo", stats.LowStockProducts},
+			{"total_usuarios", stats.TotalUsers},
+			{"valor_inventario", stats.TotalValue},
+			{"valor_venta", stats.RetailValue},
+		}
+		for _, row := range rows {
+			writer.WriteRow(row)
+		}
+		finishExport(c, writer, "estadisticas")
+
+	case "recent-movements":
+		query := config.DB.Table("movements")
+		if locationID != "" {
+			query = query.Where("movements.location_id = ?", locationID)
+		}
+		query = movementExportQuery(query).Order("movements.movement_date DESC").Limit(10)
+		streamExport(c, format, "movimientos_recientes", query, movementExportHeader, (*movementRow).cells)
+
+	case "low-stock-alerts":
+		type lowStockRow struct {
+			ID           uint
+			SKU          *string
+			Name         string
+			CategoryName *string
+			Stock        int
+		}
+		stockColumn := "products.stock"
+		if locationID != "" {
+			stockColumn = "ps.quantity"
+		}
+		query := config.DB.Table("products").
+			Select("products.id, products.sku, products.name, categories.name as category_name, " + stockColumn + " as stock").
+			Joins("LEFT JOIN categories ON categories.id = products.category_id").
+			Where("products.deleted_at IS NULL").
+			Scopes(lowStockScope(locationID))
+		header := []interface{}{"id", "sku", "producto", "categoria", "stock"}
+		streamExport(c, format, "stock_bajo", query, header, func(p *lowStockRow) []interface{} {
+			return []interface{}{p.ID, p.SKU, p.Name, p.CategoryName, p.Stock}
+		})
+
+	case "movement-summary":
+		from, to, err := parsePeriod(c, 30)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Con group_by se exporta la serie temporal en lugar del desglose por motivo
+		if groupBy := c.Query("group_by"); groupBy != "" {
+			grouping, ok := summaryGroupings[groupBy]
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "group_by inválido; use day, week o month"})
+				return
+			}
+			type seriesRow struct {
+				Period    string
+				Movements int64
+				Inbound   int64
+				Outbound  int64
+				Net       int64
+			}
+			header := []interface{}{"periodo", "movimientos", "unidades_ingresadas", "unidades_egresadas", "neto"}
+			streamExport(c, format, "serie_movimientos", movementSeries(locationID, from, to, grouping), header,
+				func(s *seriesRow) []interface{} {
+					return []interface{}{s.Period, s.Movements, s.Inbound, s.Outbound, s.Net}
+				})
+			return
+		}
+
+		type summaryRow struct {
+			Type       string
+			ReasonCode *string
+			ReasonName *string
+			Movements  int64
+			Quantity   int64
+		}
+		query := summaryMovements(locationID, from, to).
+			Select("m.type, r.code as reason_code, r.name as reason_name, COUNT(*) as movements, COALESCE(SUM(m.quantity), 0) as quantity").
+			Joins("LEFT JOIN reason_codes r ON r.id = m.reason_code_id").
+			Group("m.type, r.code, r.name").
+			Order("m.type, r.code")
+		header := []interface{}{"tipo", "motivo", "motivo_nombre", "movimientos", "cantidad"}
+		streamExport(c, format, "resumen_movimientos", query, header, func(s *summaryRow) []interface{} {
+			return []interface{}{s.Type, s.ReasonCode, s.ReasonName, s.Movements, s.Quantity}
+		})
+
+	case "top-products":
+		type topProductRow struct {
+			ProductID      uint
+			ProductName    string
+			CategoryName   string
+			CurrentStock   int
+			TotalMovements int64
+		}
+		query, err := topProductsQuery(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		header := []interface{}{"producto_id", "producto", "categoria", "stock_actual", "movimientos"}
+		streamExport(c, format, "top_productos", query, header, func(p *topProductRow) []interface{} {
+			return []interface{}{p.ProductID, p.ProductName, p.CategoryName, p.CurrentStock, p.TotalMovements}
+		})
+
+	default:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reporte no encontrado"})
+	}
+}
@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Stormdead/inventory-control-panel/backend/config"
+	"github.com/Stormdead/inventory-control-panel/backend/models"
+	"github.com/Stormdead/inventory-control-panel/backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Semanas de un ciclo estacional (un año)
+const forecastSeasonLength = 52
+
+// queryInt lee un parámetro entero entre min y max, con valor por defecto
+func queryInt(c *gin.Context, name string, def, min, max int) (int, bool) {
+	value, err := strconv.Atoi(c.DefaultQuery(name, strconv.Itoa(def)))
+	if err != nil || value < min || value > max {
+		c.JSON(http.StatusBadRequest, gin.H{"error": name + " debe ser un número entre " + strconv.Itoa(min) + " y " + strconv.Itoa(max)})
+		return 0, false
+	}
+	return value, true
+}
+
+// roundQuantity redondea una cantidad pronosticada a 2 decimales
+func roundQuantity(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// stockoutDate proyecta cuándo se agota stock con la demanda semanal pronosticada a partir
+// de now. Pasado el horizonte se extrapola con la última semana pronosticada.
+// Devuelve nil si no hay demanda, e indica si la fecha cae dentro del horizonte.
+func stockoutDate(now time.Time, stock int, weekly []float64) (*time.Time, bool) {
+	if stock <= 0 {
+		return &now, true
+	}
+
+	remaining := float64(stock)
+	for i, demand := range weekly {
+		if demand > 0 && remaining <= demand {
+			date := now.Add(time.Duration((float64(i) + remaining/demand) * 7 * 24 * float64(time.Hour)))
+			return &date, true
+		}
+		remaining -= demand
+	}
+
+	last := weekly[len(weekly)-1]
+	if last <= 0 {
+		return nil, false
+	}
+	date := now.Add(time.Duration((float64(len(weekly)) + remaining/last) * 7 * 24 * float64(time.Hour)))
+	return &date, false
+}
+
+// GET /api/dashboard/forecast - Pronóstico semanal de consumo por producto y fecha estimada de quiebre
+// (?weeks=8 horizonte, ?history_weeks=104, ?method=suavizado|media_movil, ?alpha=0.3, ?window=4,
+// ?product_id=, ?category_id=)
+//
+// La demanda semanal son las salidas no revertidas, en semanas de 7 días contadas hacia
+// atrás desde ahora. Con al menos dos años de historia se aplica estacionalidad anual.
+func GetDemandForecast(c *gin.Context) {
+	weeks, ok := queryInt(c, "weeks", 8, 1, 52)
+	if !ok {
+		return
+	}
+	historyWeeks, ok := queryInt(c, "history_weeks", 2*forecastSeasonLength, 2, 5*forecastSeasonLength)
+	if !ok {
+		return
+	}
+	window, ok := queryInt(c, "window", 4, 1, historyWeeks)
+	if !ok {
+		return
+	}
+	alpha, err := strconv.ParseFloat(c.DefaultQuery("alpha", "0.3"), 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "alpha debe ser un número entre 0 y 1"})
+		return
+	}
+	opts := utils.ForecastOptions{
+		Method:       c.DefaultQuery("method", utils.ForecastExponential),
+		Window:       window,
+		Alpha:        alpha,
+		SeasonLength: forecastSeasonLength,
+	}
+	// Validar el método y alpha antes de consultar
+	if _, err := utils.ForecastDemand([]float64{0}, 1, opts); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	since := now.AddDate(0, 0, -7*historyWeeks)
+
+	// Consumo por producto y semana (0 = los últimos 7 días)
+	var rows []struct {
+		ProductID uint
+		Week      int
+		Quantity  float64
+	}
+	consumption := summaryMovements("", since, now).
+		Select("m.product_id, FLOOR(TIMESTAMPDIFF(SECOND, m.movement_date, ?) / 604800) as week, SUM(m.quantity) as quantity", now).
+		Where(models.ConsumptionSQL("m")).
+		Group("m.product_id, week")
+	if productID := c.Query("product_id"); productID != "" {
+		consumption = consumption.Where("m.product_id = ?", productID)
+	}
+	if categoryID := c.Query("category_id"); categoryID != "" {
+		consumption = consumption.Where("m.product_id IN (?)",
+			config.DB.Model(&models.Product{}).Select("id").Where("category_id = ?", categoryID))
+	}
+	if err := consumption.Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al calcular el pronóstico"})
+		return
+	}
+
+	// Series semanales, de la más antigua a la más reciente
+	series := map[uint][]float64{}
+	for _, row := range rows {
+		if row.Week < 0 || row.Week >= historyWeeks {
+			continue
+		}
+		if series[row.ProductID] == nil {
+			series[row.ProductID] = make([]float64, historyWeeks)
+		}
+		series[row.ProductID][historyWeeks-1-row.Week] += row.Quantity
+	}
+
+	ids := make([]uint, 0, len(series))
+	for id := range series {
+		ids = append(ids, id)
+	}
+	var products []models.Product
+	if len(ids) > 0 {
+		if err := config.DB.Where("id IN ?", ids).Find(&products).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al calcular el pronóstico"})
+			return
+		}
+	}
+
+	type ForecastWeek struct {
+		WeekStart time.Time `json:"week_start"`
+		Quantity  float64   `json:"quantity"`
+	}
+	type ProductForecast struct {
+		ProductID         uint           `json:"product_id"`
+		SKU               *string        `json:"sku"`
+		ProductName       string         `json:"product_name"`
+		Stock             int            `json:"stock"`
+		HistoryWeeks      int            `json:"history_weeks"`
+		Seasonal          bool           `json:"seasonal"`
+		AverageWeekly     float64        `json:"average_weekly"`
+		Weeks             []ForecastWeek `json:"forecast"`
+		TotalForecast     float64        `json:"total_forecast"`
+		StockoutDate      *time.Time     `json:"stockout_date"`
+		StockoutInHorizon bool           `json:"stockout_in_horizon"`
+	}
+
+	forecasts := make([]ProductForecast, 0, len(products))
+	for _, product := range products {
+		// La historia empieza en la primera semana con consumo del producto
+		history := series[product.ID]
+		for len(history) > 1 && history[0] == 0 {
+			history = history[1:]
+		}
+
+		result, err := utils.ForecastDemand(history, weeks, opts)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		forecast := ProductForecast{
+			ProductID:     product.ID,
+			SKU:           product.SKU,
+			ProductName:   product.Name,
+			Stock:         product.Stock,
+			HistoryWeeks:  len(history),
+			Seasonal:      result.Seasonal,
+			AverageWeekly: roundQuantity(result.Level),
+			Weeks:         make([]ForecastWeek, weeks),
+		}
+		for i, value := range result.Values {
+			forecast.Weeks[i] = ForecastWeek{WeekStart: now.AddDate(0, 0, 7*i), Quantity: roundQuantity(value)}
+			forecast.TotalForecast += value
+		}
+		forecast.TotalForecast = roundQuantity(forecast.TotalForecast)
+		forecast.StockoutDate, forecast.StockoutInHorizon = stockoutDate(now, product.Stock, result.Values)
+
+		forecasts = append(forecasts, forecast)
+	}
+
+	// Primero los que se agotan antes; los que no tienen fecha al final
+	sort.SliceStable(forecasts, func(i, j int) bool {
+		a, b := forecasts[i].StockoutDate, forecasts[j].StockoutDate
+		switch {
+		case a == nil:
+			return false
+		case b == nil:
+			return true
+		}
+		return a.Before(*b)
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"products":      forecasts,
+		"total":         len(forecasts),
+		"method":        opts.Method,
+		"weeks":         weeks,
+		"history_weeks": historyWeeks,
+	})
+}
@@ -0,0 +1,438 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Stormdead/inventory-control-panel/backend/config"
+	"github.com/Stormdead/inventory-control-panel/backend/models"
+	"github.com/Stormdead/inventory-control-panel/backend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Máximo de filas por archivo de importación
+const maxImportRows = 10000
+
+// Tamaño máximo de la petición de importación (archivo y campos del formulario)
+const maxImportBytes = 10 << 20
+
+// importColumnAliases relaciona los encabezados habituales con los campos del producto
+var importColumnAliases = map[string]string{
+	"name": "name", "nombre": "name", "producto": "name",
+	"description": "description", "descripcion": "description", "descripción": "description",
+	"price": "price", "precio": "price",
+	"cost": "cost", "costo": "cost", "costo_unitario": "cost",
+	"stock": "stock", "cantidad": "stock",
+	"category": "category", "categoria": "category", "categoría": "category",
+	"sku": "sku", "codigo": "sku", "código": "sku",
+	"min_stock": "min_stock", "stock_minimo": "min_stock", "stock mínimo": "min_stock",
+	"max_stock": "max_stock", "stock_maximo": "max_stock", "stock máximo": "max_stock",
+	"reorder_point": "reorder_point", "punto_pedido": "reorder_point", "punto de pedido": "reorder_point",
+	"barcode": "barcode", "codigo_barras": "barcode", "código de barras": "barcode", "ean": "barcode",
+	"image_url": "image_url", "imagen": "image_url",
+}
+
+// ImportRowError es un error de validación de una fila del archivo
+type ImportRowError struct {
+	Row    int      `json:"row"`
+	Errors []string `json:"errors"`
+}
+
+// errImportConflict anula una importación todo o nada cuando una fila choca con un producto existente
+var errImportConflict = errors.New("conflicto de unicidad en la importación")
+
+// importRow es una fila ya interpretada
+type importRow struct {
+	Number       int
+	Product      models.Product
+	CategoryName string
+}
+
+// mapImportColumns resuelve qué columna del archivo corresponde a cada campo.
+// mapping permite indicar encabezados propios: {"Nombre del artículo": "name"}.
+func mapImportColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	columns := map[string]int{}
+	for i, title := range header {
+		key := strings.ToLower(strings.TrimSpace(title))
+		field, ok := mapping[strings.TrimSpace(title)]
+		if !ok {
+			field, ok = importColumnAliases[key]
+		}
+		if ok {
+			columns[field] = i
+		}
+	}
+
+	if _, ok := columns["name"]; !ok {
+		return nil, errors.New("falta la columna del nombre (name)")
+	}
+	if _, ok := columns["price"]; !ok {
+		return nil, errors.New("falta la columna del precio (price)")
+	}
+	return columns, nil
+}
+
+// parseImportRow convierte una fila en un producto y devuelve los errores de formato
+func parseImportRow(values []string, columns map[string]int) (models.Product, string, []string) {
+	get := func(field string) string {
+		i, ok := columns[field]
+		if !ok || i >= len(values) {
+			return ""
+		}
+		return strings.TrimSpace(values[i])
+	}
+
+	var product models.Product
+	var problems []string
+
+	product.Name = get("name")
+	product.Description = get("description")
+	product.ImageURL = get("image_url")
+
+	if sku := get("sku"); sku != "" {
+		product.SKU = &sku
+	}
+
+	if price := get("price"); price != "" {
+		value, err := strconv.ParseFloat(strings.ReplaceAll(price, ",", "."), 64)
+		if err != nil {
+			problems = append(problems, "El precio no es un número válido")
+		}
+		product.Price = value
+	}
+
+	// El costo es el costo unitario del stock inicial
+	if cost := get("cost"); cost != "" {
+		value, err := strconv.ParseFloat(strings.ReplaceAll(cost, ",", "."), 64)
+		if err != nil {
+			problems = append(problems, "El costo no es un número válido")
+		}
+		product.AverageCost = value
+	}
+
+	if stock := get("stock"); stock != "" {
+		value, err := strconv.Atoi(stock)
+		if err != nil {
+			// Las planillas suelen guardar enteros como "10.0"
+			f, ferr := strconv.ParseFloat(stock, 64)
+			if ferr != nil || f != float64(int(f)) {
+				problems = append(problems, "El stock debe ser un número entero")
+			}
+			value = int(f)
+		}
+		product.Stock = value
+	}
+
+	// Niveles de stock opcionales; vacío usa el de la categoría
+	levels := []struct {
+		field string
+		name  string
+		value **int
+	}{
+		{"min_stock", "El stock mínimo", &product.MinStock},
+		{"max_stock", "El stock máximo", &product.MaxStock},
+		{"reorder_point", "El punto de pedido", &product.ReorderPoint},
+	}
+	for _, level := range levels {
+		if text := get(level.field); text != "" {
+			value, err := strconv.Atoi(text)
+			if err != nil {
+				problems = append(problems, level.name+" debe ser un número entero")
+				continue
+			}
+			*level.value = &value
+		}
+	}
+
+	// Varios códigos de barras separados por "|"
+	for _, code := range strings.Split(get("barcode"), "|") {
+		if code = strings.TrimSpace(code); code != "" {
+			product.Barcodes = append(product.Barcodes, models.ProductBarcode{Code: code})
+		}
+	}
+
+	return product, get("category"), problems
+}
+
+// POST /api/products/import - Importar productos desde CSV o XLSX (solo admin)
+//
+// Formulario multipart:
+//   - file: archivo .csv o .xlsx con encabezados en la primera fila
+//   - dry_run=true: solo valida y reporta errores por fila, sin guardar
+//   - create_categories=true: crea las categorías que no existan
+//   - mapping: JSON con encabezados propios, ej. {"Artículo": "name"}
+//   - chunk_size=N: guarda en bloques de N filas; si un bloque falla, la respuesta
+//     indica next_row para reanudar con start_row
+func ImportProducts(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+
+	fileHeader, err := c.FormFile("file")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "El archivo supera el tamaño máximo de " + strconv.Itoa(maxImportBytes>>20) + " MB"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El archivo es requerido (campo file)"})
+		return
+	}
+
+	dryRun := c.PostForm("dry_run") == "true" || c.Query("dry_run") == "true"
+	createCategories := c.PostForm("create_categories") == "true" || c.Query("create_categories") == "true"
+
+	chunkSize, _ := strconv.Atoi(c.PostForm("chunk_size"))
+	startRow, _ := strconv.Atoi(c.PostForm("start_row"))
+	if startRow < 2 {
+		startRow = 2
+	}
+
+	mapping := map[string]string{}
+	if raw := c.PostForm("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "mapping debe ser un objeto JSON"})
+			return
+		}
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No se pudo leer el archivo"})
+		return
+	}
+	defer file.Close()
+
+	records, err := utils.ReadSpreadsheet(fileHeader.Filename, file, maxImportRows+1)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(records) < 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El archivo no tiene filas de datos"})
+		return
+	}
+	if len(records)-1 > maxImportRows {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El archivo supera el máximo de " + strconv.Itoa(maxImportRows) + " filas"})
+		return
+	}
+
+	columns, err := mapImportColumns(records[0], mapping)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Categorías existentes por nombre (sin distinguir mayúsculas)
+	var categories []models.Category
+	config.DB.Find(&categories)
+	categoryIDs := map[string]uint{}
+	for _, category := range categories {
+		categoryIDs[strings.ToLower(category.Name)] = category.ID
+	}
+
+	var rows []importRow
+	var rowErrors []ImportRowError
+	newCategories := map[string]string{}
+	seenSKUs, seenCodes := map[string]int{}, map[string]int{}
+
+	for i, values := range records[1:] {
+		number := i + 2
+		if number < startRow || isBlankRow(values) {
+			continue
+		}
+
+		product, categoryName, problems := parseImportRow(values, columns)
+
+		if categoryName != "" {
+			if id, ok := categoryIDs[strings.ToLower(categoryName)]; ok {
+				product.CategoryID = &id
+			} else if createCategories {
+				newCategories[strings.ToLower(categoryName)] = categoryName
+			} else {
+				problems = append(problems, "La categoría especificada no existe: "+categoryName)
+			}
+		}
+
+		// Las mismas reglas que POST /api/products
+		if err := validateProduct(config.DB, &product); err != nil {
+			problems = append(problems, err.Error())
+		}
+
+		// Duplicados dentro del mismo archivo, incluido un SKU usado como código de barras en otra fila
+		if product.SKU != nil {
+			if first, ok := seenSKUs[*product.SKU]; ok {
+				problems = append(problems, "SKU repetido en la fila "+strconv.Itoa(first))
+			} else {
+				seenSKUs[*product.SKU] = number
+			}
+			for _, code := range utils.EquivalentCodes(*product.SKU) {
+				if first, ok := seenCodes[code]; ok && first != number {
+					problems = append(problems, "El SKU es el código de barras de la fila "+strconv.Itoa(first))
+				}
+			}
+		}
+		for _, barcode := range product.Barcodes {
+			if first, ok := seenCodes[barcode.Code]; ok {
+				problems = append(problems, "Código "+barcode.Code+" repetido en la fila "+strconv.Itoa(first))
+			} else {
+				seenCodes[barcode.Code] = number
+			}
+			for _, code := range utils.EquivalentCodes(barcode.Code) {
+				if first, ok := seenSKUs[code]; ok && first != number {
+					problems = append(problems, "Código "+barcode.Code+" usado como SKU en la fila "+strconv.Itoa(first))
+				}
+			}
+		}
+
+		if len(problems) > 0 {
+			rowErrors = append(rowErrors, ImportRowError{Row: number, Errors: problems})
+			continue
+		}
+		rows = append(rows, importRow{Number: number, Product: product, CategoryName: categoryName})
+	}
+
+	categoriesToCreate := make([]string, 0, len(newCategories))
+	for _, name := range newCategories {
+		categoriesToCreate = append(categoriesToCreate, name)
+	}
+
+	summary := gin.H{
+		"dry_run":              dryRun,
+		"total_rows":           len(rows) + len(rowErrors),
+		"valid_rows":           len(rows),
+		"errors":               rowErrors,
+		"categories_to_create": categoriesToCreate,
+	}
+
+	if dryRun {
+		c.JSON(http.StatusOK, summary)
+		return
+	}
+
+	// Sin bloques la importación es todo o nada; con bloques se omiten las filas con errores
+	allOrNothing := chunkSize <= 0
+	if len(rowErrors) > 0 && allOrNothing {
+		summary["error"] = "El archivo tiene filas con errores; no se importó ningún producto"
+		c.JSON(http.StatusUnprocessableEntity, summary)
+		return
+	}
+
+	if allOrNothing {
+		chunkSize = len(rows)
+	}
+
+	imported := 0
+	for start := 0; start < len(rows); start += chunkSize {
+		end := start + chunkSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		chunk := rows[start:end]
+
+		// Las categorías creadas y los conflictos del bloque solo se toman si el bloque se confirma
+		chunkCategories := map[string]uint{}
+		var chunkErrors []ImportRowError
+		created := 0
+
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			for i := range chunk {
+				if err := resolveImportCategory(tx, &chunk[i], categoryIDs, chunkCategories); err != nil {
+					return err
+				}
+
+				// La unicidad de SKU y códigos se validó fuera de la transacción: si otra
+				// petición los registró entretanto, la fila se descarta con su error
+				if err := tx.SavePoint("import_row").Error; err != nil {
+					return err
+				}
+				if err := createProduct(tx, &chunk[i].Product, userID.(uint)); err != nil {
+					if !isDuplicateKeyError(err) {
+						return err
+					}
+					chunkErrors = append(chunkErrors, ImportRowError{
+						Row:    chunk[i].Number,
+						Errors: []string{"El SKU o un código de barras ya pertenece a otro producto"},
+					})
+					if allOrNothing {
+						return errImportConflict
+					}
+					if err := tx.RollbackTo("import_row").Error; err != nil {
+						return err
+					}
+					continue
+				}
+				created++
+			}
+			return nil
+		})
+		if errors.Is(err, errImportConflict) {
+			summary["errors"] = append(rowErrors, chunkErrors...)
+			summary["error"] = "El archivo tiene filas con errores; no se importó ningún producto"
+			c.JSON(http.StatusUnprocessableEntity, summary)
+			return
+		}
+		if err != nil {
+			summary["error"] = "Error al guardar el bloque que empieza en la fila " + strconv.Itoa(chunk[0].Number)
+			summary["imported"] = imported
+			summary["next_row"] = chunk[0].Number
+			c.JSON(http.StatusInternalServerError, summary)
+			return
+		}
+
+		for key, id := range chunkCategories {
+			categoryIDs[key] = id
+		}
+		rowErrors = append(rowErrors, chunkErrors...)
+		summary["errors"] = rowErrors
+		imported += created
+	}
+
+	summary["message"] = "Importación completada"
+	summary["imported"] = imported
+	c.JSON(http.StatusOK, summary)
+}
+
+// resolveImportCategory asigna la categoría de la fila, creándola si hace falta.
+// Las categorías creadas se anotan en chunkCategories y no en categoryIDs, porque
+// todavía pueden deshacerse si falla la transacción del bloque.
+func resolveImportCategory(tx *gorm.DB, row *importRow, categoryIDs, chunkCategories map[string]uint) error {
+	if row.CategoryName == "" || row.Product.CategoryID != nil {
+		return nil
+	}
+
+	key := strings.ToLower(row.CategoryName)
+	if id, ok := categoryIDs[key]; ok {
+		row.Product.CategoryID = &id
+		return nil
+	}
+	if id, ok := chunkCategories[key]; ok {
+		row.Product.CategoryID = &id
+		return nil
+	}
+
+	category := models.Category{Name: row.CategoryName}
+	if err := tx.Create(&category).Error; err != nil {
+		return err
+	}
+	chunkCategories[key] = category.ID
+	row.Product.CategoryID = &category.ID
+	return nil
+}
+
+func isBlankRow(values []string) bool {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
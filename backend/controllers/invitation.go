@@ -0,0 +1,261 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Stormdead/inventory-control-panel/backend/config"
+	"github.com/Stormdead/inventory-control-panel/backend/models"
+	"github.com/Stormdead/inventory-control-panel/backend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Vigencia de una invitación cuando no se indica otra
+const defaultInvitationHours = 72
+
+type CreateInvitationRequest struct {
+	Email          string `json:"email" binding:"required,email"`
+	Role           string `json:"role" binding:"required"`
+	ExpiresInHours int    `json:"expires_in_hours" binding:"omitempty,min=1,max=720"`
+}
+
+type AcceptInvitationRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Username string `json:"username" binding:"required,min=3,max=50"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+// withStatus completa el estado calculado de las invitaciones
+func withStatus(invitations []models.Invitation) []models.Invitation {
+	now := time.Now()
+	for i := range invitations {
+		invitations[i].Status = invitations[i].CurrentStatus(now)
+	}
+	return invitations
+}
+
+// GET /api/invitations - Listar invitaciones (?status=pendiente|aceptada|revocada|expirada)
+func GetInvitations(c *gin.Context) {
+	var invitations []models.Invitation
+
+	query := config.DB.Preload("InvitedBy", unscoped).Order("created_at DESC")
+	now := time.Now()
+	switch c.Query("status") {
+	case "":
+	case models.InvitationStatusPendiente:
+		query = query.Where("accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", now)
+	case models.InvitationStatusAceptada:
+		query = query.Where("accepted_at IS NOT NULL")
+	case models.InvitationStatusRevocada:
+		query = query.Where("accepted_at IS NULL AND revoked_at IS NOT NULL")
+	case models.InvitationStatusExpirada:
+		query = query.Where("accepted_at IS NULL AND revoked_at IS NULL AND expires_at <= ?", now)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Estado de invitación inválido"})
+		return
+	}
+
+	if err := query.Find(&invitations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener invitaciones"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"invitations": withStatus(invitations),
+		"total":       len(invitations),
+	})
+}
+
+// POST /api/invitations - Invitar a un usuario con un rol (solo admin).
+// El token se devuelve una única vez; solo su hash queda guardado.
+func CreateInvitation(c *gin.Context) {
+	var req CreateInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if status, err := assignableRole(c, req.Role); err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	if req.ExpiresInHours == 0 {
+		req.ExpiresInHours = defaultInvitationHours
+	}
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+
+	// El email es único también entre los usuarios eliminados
+	var existing int64
+	config.DB.Unscoped().Model(&models.User{}).Where("email = ?", email).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Ya existe un usuario con ese email"})
+		return
+	}
+
+	token, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al generar la invitación"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	invitation := models.Invitation{
+		Email:       email,
+		Role:        req.Role,
+		TokenHash:   utils.HashToken(token),
+		ExpiresAt:   time.Now().Add(time.Duration(req.ExpiresInHours) * time.Hour),
+		InvitedByID: userID.(uint),
+	}
+
+	tx := config.DB.Begin()
+
+	// Una invitación nueva reemplaza a las pendientes del mismo email
+	if err := tx.Model(&models.Invitation{}).
+		Where("email = ? AND accepted_at IS NULL AND revoked_at IS NULL", email).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear la invitación"})
+		return
+	}
+	if err := tx.Create(&invitation).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear la invitación"})
+		return
+	}
+
+	tx.Commit()
+
+	invitation.Status = invitation.CurrentStatus(time.Now())
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Invitación creada exitosamente",
+		"invitation": invitation,
+		"token":      token,
+	})
+}
+
+// DELETE /api/invitations/:id - Revocar una invitación pendiente (solo admin)
+func RevokeInvitation(c *gin.Context) {
+	var invitation models.Invitation
+	if err := config.DB.First(&invitation, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invitación no encontrada"})
+		return
+	}
+
+	if invitation.AcceptedAt != nil || invitation.RevokedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "La invitación ya fue " + invitation.CurrentStatus(time.Now())})
+		return
+	}
+
+	now := time.Now()
+	if err := config.DB.Model(&invitation).Update("revoked_at", now).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al revocar la invitación"})
+		return
+	}
+
+	invitation.Status = invitation.CurrentStatus(now)
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Invitación revocada exitosamente",
+		"invitation": invitation,
+	})
+}
+
+// findPendingInvitation busca la invitación de un token y verifica que siga pendiente
+func findPendingInvitation(db *gorm.DB, token string) (*models.Invitation, int, error) {
+	var invitation models.Invitation
+	if err := db.Where("token_hash = ?", utils.HashToken(token)).First(&invitation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, http.StatusNotFound, errors.New("Invitación no encontrada")
+		}
+		return nil, http.StatusInternalServerError, errors.New("Error al obtener la invitación")
+	}
+
+	if status := invitation.CurrentStatus(time.Now()); status != models.InvitationStatusPendiente {
+		return nil, http.StatusGone, errors.New("La invitación está " + status)
+	}
+	return &invitation, 0, nil
+}
+
+// GET /api/auth/invitations/:token - Consultar una invitación antes de aceptarla (pública)
+func GetInvitationByToken(c *gin.Context) {
+	invitation, status, err := findPendingInvitation(config.DB, c.Param("token"))
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"invitation": gin.H{
+			"email":      invitation.Email,
+			"role":       invitation.Role,
+			"expires_at": invitation.ExpiresAt,
+		},
+	})
+}
+
+// POST /api/auth/invitations/accept - Aceptar una invitación definiendo usuario y contraseña (pública).
+// Funciona aunque el registro público esté deshabilitado; el token sirve una sola vez.
+func AcceptInvitation(c *gin.Context) {
+	var req AcceptInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hashedPassword, err := hashPassword(req.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al procesar la contraseña"})
+		return
+	}
+
+	tx := config.DB.Begin()
+
+	// El bloqueo evita que el mismo token se canjee dos veces en paralelo
+	invitation, status, err := findPendingInvitation(tx.Clauses(clause.Locking{Strength: "UPDATE"}), req.Token)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	var existing int64
+	tx.Unscoped().Model(&models.User{}).Where("email = ? OR username = ?", invitation.Email, req.Username).Count(&existing)
+	if existing > 0 {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": "El usuario o email ya existe"})
+		return
+	}
+
+	user := models.User{
+		Username: req.Username,
+		Email:    invitation.Email,
+		Password: hashedPassword,
+		Role:     invitation.Role,
+	}
+	if err := tx.Create(&user).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear usuario"})
+		return
+	}
+
+	if err := tx.Model(invitation).Updates(map[string]interface{}{
+		"accepted_at":      time.Now(),
+		"accepted_user_id": user.ID,
+	}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al aceptar la invitación"})
+		return
+	}
+
+	tokens, err := issueTokens(tx, user, "")
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al generar token"})
+		return
+	}
+
+	tx.Commit()
+	c.JSON(http.StatusCreated, authResponse("Invitación aceptada exitosamente", tokens, user))
+}
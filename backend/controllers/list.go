@@ -0,0 +1,308 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Stormdead/inventory-control-panel/backend/models"
+	"github.com/Stormdead/inventory-control-panel/backend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Tamaño de página por defecto y máximo para los listados
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 500
+)
+
+// listQuery son los parámetros de paginación por cursor de un listado:
+// ?limit=50&cursor=<next_cursor>&sort=-created_at
+type listQuery struct {
+	Limit     int
+	Cursor    *utils.Cursor
+	SortKey   string
+	SortDesc  bool
+	sortField string
+}
+
+// parseListQuery lee limit, cursor y sort. sorts relaciona cada clave pública
+// de ordenamiento con su columna; defaultSort puede llevar "-" para orden descendente.
+func parseListQuery(c *gin.Context, sorts map[string]string, defaultSort string) (*listQuery, error) {
+	q := &listQuery{Limit: defaultPageLimit}
+
+	if limit := c.Query("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value <= 0 {
+			return nil, errors.New("limit debe ser un número mayor a 0")
+		}
+		if value > maxPageLimit {
+			value = maxPageLimit
+		}
+		q.Limit = value
+	}
+
+	sort := c.DefaultQuery("sort", defaultSort)
+	q.SortDesc = strings.HasPrefix(sort, "-")
+	q.SortKey = strings.TrimPrefix(sort, "-")
+	field, ok := sorts[q.SortKey]
+	if !ok {
+		keys := make([]string, 0, len(sorts))
+		for key := range sorts {
+			keys = append(keys, key)
+		}
+		return nil, errors.New("sort inválido; use uno de: " + strings.Join(keys, ", "))
+	}
+	q.sortField = field
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		decoded, err := utils.DecodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		q.Cursor = decoded
+	}
+
+	return q, nil
+}
+
+// apply agrega el orden, la condición del cursor y el límite (uno extra para saber si hay más)
+func (q *listQuery) apply(db *gorm.DB, idColumn string) *gorm.DB {
+	op, direction := ">", "ASC"
+	if q.SortDesc {
+		op, direction = "<", "DESC"
+	}
+
+	if q.Cursor != nil {
+		db = db.Where("("+q.sortField+" "+op+" ?) OR ("+q.sortField+" = ? AND "+idColumn+" "+op+" ?)",
+			q.Cursor.Value, q.Cursor.Value, q.Cursor.ID)
+	}
+
+	return q.order(db, idColumn, direction).Limit(q.Limit + 1)
+}
+
+// orderAll aplica solo el orden pedido, sin cursor ni límite (para exportaciones)
+func (q *listQuery) orderAll(db *gorm.DB, idColumn string) *gorm.DB {
+	direction := "ASC"
+	if q.SortDesc {
+		direction = "DESC"
+	}
+	return q.order(db, idColumn, direction)
+}
+
+func (q *listQuery) order(db *gorm.DB, idColumn, direction string) *gorm.DB {
+	return db.Order(q.sortField + " " + direction).Order(idColumn + " " + direction)
+}
+
+// paginate recorta el elemento extra y devuelve el cursor de la siguiente página ("" si no hay más).
+// key devuelve el valor del campo de ordenamiento y el ID de un elemento.
+func paginate[T any](items []T, q *listQuery, key func(T, string) (interface{}, uint)) ([]T, string) {
+	if len(items) <= q.Limit {
+		return items, ""
+	}
+
+	items = items[:q.Limit]
+	value, id := key(items[len(items)-1], q.SortKey)
+	return items, utils.EncodeCursor(value, id)
+}
+
+// parseDateParam acepta "2006-01-02" o RFC3339. Con endOfDay, una fecha sin hora
+// se toma como el final de ese día para que los rangos sean inclusivos.
+func parseDateParam(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, errors.New("fecha inválida: " + value + " (use YYYY-MM-DD o RFC3339)")
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
+
+// parsePeriod lee ?from=&to= (ver parseDateParam). Sin to se usa ahora y sin from,
+// los defaultDays días anteriores a to.
+func parsePeriod(c *gin.Context, defaultDays int) (time.Time, time.Time, error) {
+	to := time.Now()
+	if value := c.Query("to"); value != "" {
+		parsed, err := parseDateParam(value, true)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, -defaultDays)
+	if value := c.Query("from"); value != "" {
+		parsed, err := parseDateParam(value, false)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		from = parsed
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, errors.New("from debe ser anterior a to")
+	}
+	return from, to, nil
+}
+
+// Campos por los que se pueden ordenar los listados
+var (
+	productSorts = map[string]string{
+		"id": "products.id", "name": "products.name", "price": "products.price",
+		"stock": "products.stock", "created_at": "products.created_at",
+	}
+	movementSorts = map[string]string{
+		"id": "movements.id", "movement_date": "movements.movement_date", "quantity": "movements.quantity",
+	}
+	categorySorts = map[string]string{
+		"id": "categories.id", "name": "categories.name", "created_at": "categories.created_at",
+	}
+	userSorts = map[string]string{
+		"id": "users.id", "username": "users.username", "email": "users.email", "created_at": "users.created_at",
+	}
+)
+
+// productSortValue devuelve el valor del campo de ordenamiento de un producto
+func productSortValue(p models.Product, sortKey string) (interface{}, uint) {
+	switch sortKey {
+	case "name":
+		return p.Name, p.ID
+	case "price":
+		return p.Price, p.ID
+	case "stock":
+		return p.Stock, p.ID
+	case "created_at":
+		return p.CreatedAt, p.ID
+	}
+	return p.ID, p.ID
+}
+
+// movementSortValue devuelve el valor del campo de ordenamiento de un movimiento
+func movementSortValue(m models.Movement, sortKey string) (interface{}, uint) {
+	switch sortKey {
+	case "movement_date":
+		return m.MovementDate, m.ID
+	case "quantity":
+		return m.Quantity, m.ID
+	}
+	return m.ID, m.ID
+}
+
+// categorySortValue devuelve el valor del campo de ordenamiento de una categoría
+func categorySortValue(cat models.Category, sortKey string) (interface{}, uint) {
+	switch sortKey {
+	case "name":
+		return cat.Name, cat.ID
+	case "created_at":
+		return cat.CreatedAt, cat.ID
+	}
+	return cat.ID, cat.ID
+}
+
+// userSortValue devuelve el valor del campo de ordenamiento de un usuario
+func userSortValue(u models.User, sortKey string) (interface{}, uint) {
+	switch sortKey {
+	case "username":
+		return u.Username, u.ID
+	case "email":
+		return u.Email, u.ID
+	case "created_at":
+		return u.CreatedAt, u.ID
+	}
+	return u.ID, u.ID
+}
+
+// filterProducts aplica los filtros de productos de la query string:
+// search (nombre o SKU), category_id, min_stock, max_stock, min_price, max_price
+func filterProducts(c *gin.Context, db *gorm.DB) (*gorm.DB, error) {
+	if search := strings.TrimSpace(c.Query("search")); search != "" {
+		db = db.Where("products.name LIKE ? OR products.sku = ?", "%"+search+"%", search)
+	}
+
+	if categoryID := c.Query("category_id"); categoryID != "" {
+		db = db.Where("products.category_id = ?", categoryID)
+	}
+
+	intFilters := []struct{ param, condition string }{
+		{"min_stock", "products.stock >= ?"},
+		{"max_stock", "products.stock <= ?"},
+	}
+	for _, f := range intFilters {
+		if value := c.Query(f.param); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, errors.New(f.param + " debe ser un número entero")
+			}
+			db = db.Where(f.condition, n)
+		}
+	}
+
+	floatFilters := []struct{ param, condition string }{
+		{"min_price", "products.price >= ?"},
+		{"max_price", "products.price <= ?"},
+	}
+	for _, f := range floatFilters {
+		if value := c.Query(f.param); value != "" {
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, errors.New(f.param + " debe ser un número")
+			}
+			db = db.Where(f.condition, n)
+		}
+	}
+
+	return db, nil
+}
+
+// filterMovements aplica los filtros de movimientos de la query string:
+// from, to, product_id, user_id, type, location_id, category_id
+func filterMovements(c *gin.Context, db *gorm.DB) (*gorm.DB, error) {
+	if from := c.Query("from"); from != "" {
+		t, err := parseDateParam(from, false)
+		if err != nil {
+			return nil, err
+		}
+		db = db.Where("movements.movement_date >= ?", t)
+	}
+
+	if to := c.Query("to"); to != "" {
+		t, err := parseDateParam(to, true)
+		if err != nil {
+			return nil, err
+		}
+		db = db.Where("movements.movement_date <= ?", t)
+	}
+
+	if productID := c.Query("product_id"); productID != "" {
+		db = db.Where("movements.product_id = ?", productID)
+	}
+
+	if userID := c.Query("user_id"); userID != "" {
+		db = db.Where("movements.user_id = ?", userID)
+	}
+
+	if locationID := c.Query("location_id"); locationID != "" {
+		db = db.Where("movements.location_id = ?", locationID)
+	}
+
+	if movementType := c.Query("type"); movementType != "" {
+		if models.MovementSign(movementType) == 0 {
+			return nil, errors.New("tipo de movimiento inválido")
+		}
+		db = db.Where("movements.type = ?", movementType)
+	}
+
+	if categoryID := c.Query("category_id"); categoryID != "" {
+		db = db.Where("movements.product_id IN (?)",
+			db.Session(&gorm.Session{NewDB: true}).Model(&models.Product{}).Select("id").Where("category_id = ?", categoryID))
+	}
+
+	return db, nil
+}
@@ -0,0 +1,214 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Stormdead/inventory-control-panel/backend/config"
+	"github.com/Stormdead/inventory-control-panel/backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// GET /api/locations - Listar todas las ubicaciones
+func GetLocations(c *gin.Context) {
+	var locations []models.Location
+
+	if err := config.DB.Order("name").Find(&locations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener ubicaciones"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"locations": locations,
+		"total":     len(locations),
+	})
+}
+
+// GET /api/locations/:id - Obtener una ubicación por ID
+func GetLocation(c *gin.Context) {
+	id := c.Param("id")
+	var location models.Location
+
+	if err := config.DB.First(&location, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ubicación no encontrada"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"location": location,
+	})
+}
+
+// GET /api/locations/:id/stock - Stock de cada producto en una ubicación
+func GetLocationStock(c *gin.Context) {
+	id := c.Param("id")
+	var location models.Location
+
+	if err := config.DB.First(&location, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ubicación no encontrada"})
+		return
+	}
+
+	var stocks []models.ProductStock
+	if err := config.DB.Preload("Product").Preload("Product.Category").
+		Where("location_id = ? AND quantity <> 0", location.ID).
+		Find(&stocks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener stock"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"location": location,
+		"stocks":   stocks,
+		"total":    len(stocks),
+	})
+}
+
+// POST /api/locations - Crear una ubicación (solo admin)
+func CreateLocation(c *gin.Context) {
+	var location models.Location
+
+	if err := c.ShouldBindJSON(&location); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if location.Name == "" || location.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El nombre y el código son requeridos"})
+		return
+	}
+
+	if location.Type != "" && location.Type != "almacen" && location.Type != "tienda" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El tipo debe ser 'almacen' o 'tienda'"})
+		return
+	}
+
+	// Solo la ubicación creada al iniciar es la ubicación por defecto
+	location.IsDefault = false
+
+	var existing models.Location
+	if err := config.DB.Where("code = ?", location.Code).First(&existing).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Ya existe una ubicación con ese código"})
+		return
+	}
+
+	if err := config.DB.Create(&location).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear ubicación"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Ubicación creada exitosamente",
+		"location": location,
+	})
+}
+
+// PUT /api/locations/:id - Actualizar una ubicación (solo admin)
+func UpdateLocation(c *gin.Context) {
+	id := c.Param("id")
+	var location models.Location
+
+	if err := config.DB.First(&location, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ubicación no encontrada"})
+		return
+	}
+
+	// La dirección es un puntero para distinguir "no enviada" de "vaciar"
+	var updateData struct {
+		Name    string  `json:"name"`
+		Code    string  `json:"code"`
+		Type    string  `json:"type"`
+		Address *string `json:"address"`
+	}
+	if err := c.ShouldBindJSON(&updateData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if updateData.Type != "" && updateData.Type != "almacen" && updateData.Type != "tienda" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El tipo debe ser 'almacen' o 'tienda'"})
+		return
+	}
+
+	if updateData.Name != "" {
+		location.Name = updateData.Name
+	}
+	if updateData.Code != "" && updateData.Code != location.Code {
+		var existing models.Location
+		if err := config.DB.Where("code = ?", updateData.Code).First(&existing).Error; err == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Ya existe una ubicación con ese código"})
+			return
+		}
+		location.Code = updateData.Code
+	}
+	if updateData.Type != "" {
+		location.Type = updateData.Type
+	}
+	if updateData.Address != nil {
+		location.Address = *updateData.Address
+	}
+
+	if err := config.DB.Save(&location).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar ubicación"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Ubicación actualizada exitosamente",
+		"location": location,
+	})
+}
+
+// DELETE /api/locations/:id - Eliminar una ubicación sin stock (solo admin)
+func DeleteLocation(c *gin.Context) {
+	id := c.Param("id")
+	var location models.Location
+
+	if err := config.DB.First(&location, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ubicación no encontrada"})
+		return
+	}
+
+	if location.IsDefault {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No se puede eliminar la ubicación por defecto"})
+		return
+	}
+
+	// Una ubicación con stock o historial no se puede eliminar
+	var stockCount, movementCount int64
+	config.DB.Model(&models.ProductStock{}).Where("location_id = ? AND quantity <> 0", location.ID).Count(&stockCount)
+	config.DB.Model(&models.Movement{}).Where("location_id = ?", location.ID).Count(&movementCount)
+	if stockCount > 0 || movementCount > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "La ubicación tiene stock o movimientos registrados"})
+		return
+	}
+
+	// Tampoco si es el destino de una transferencia que todavía no se recibió
+	var inTransit int64
+	config.DB.Model(&models.Transfer{}).
+		Where("to_location_id = ? AND status = ?", location.ID, models.TransferStatusEnTransito).
+		Count(&inTransit)
+	if inTransit > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "La ubicación es destino de transferencias en tránsito"})
+		return
+	}
+
+	// Las filas de stock en cero se eliminan junto con la ubicación
+	tx := config.DB.Begin()
+
+	if err := tx.Where("location_id = ?", location.ID).Delete(&models.ProductStock{}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar ubicación"})
+		return
+	}
+	if err := tx.Delete(&location).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar ubicación"})
+		return
+	}
+
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Ubicación eliminada exitosamente",
+	})
+}
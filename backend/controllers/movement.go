@@ -1,28 +1,67 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/Stormdead/inventory-control-panel/backend/config"
 	"github.com/Stormdead/inventory-control-panel/backend/models"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-// GET /api/movements - Listar todos los movimientos
+// GET /api/movements - Listar movimientos con paginación por cursor, filtros y orden
+// Filtros: from, to, product_id, user_id, type, location_id, category_id. Orden: sort=-movement_date...
 func GetMovements(c *gin.Context) {
+	listMovements(c, config.DB.Model(&models.Movement{}), nil)
+}
+
+// listMovements pagina y filtra los movimientos de la consulta base.
+// extra se agrega a la respuesta (ej. el tipo consultado).
+func listMovements(c *gin.Context, base *gorm.DB, extra gin.H) {
+	q, err := parseListQuery(c, movementSorts, "-movement_date")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query, err := filterMovements(c, base)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener movimientos"})
+		return
+	}
+
 	var movements []models.Movement
 
-	// Incluir relaciones con Product y User
-	if err := config.DB.Preload("Product").Preload("Product.Category").Preload("User").Order("movement_date DESC").Find(&movements).Error; err != nil {
+	// Las relaciones se cargan solo para la página actual
+	query = query.Preload("Product").Preload("Product.Category").Preload("User", unscoped).Preload("Location").Preload("ReasonCode")
+	if err := q.apply(query, "movements.id").Find(&movements).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener movimientos"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"movements": movements,
-		"total":     len(movements),
-	})
+	movements, nextCursor := paginate(movements, q, movementSortValue)
+
+	response := gin.H{
+		"movements":   movements,
+		"total":       total,
+		"next_cursor": nextCursor,
+	}
+	for key, value := range extra {
+		response[key] = value
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 // GET /api/movements/:id - Obtener un movimiento por ID
@@ -30,7 +69,7 @@ func GetMovement(c *gin.Context) {
 	id := c.Param("id")
 	var movement models.Movement
 
-	if err := config.DB.Preload("Product").Preload("Product.Category").Preload("User").First(&movement, id).Error; err != nil {
+	if err := config.DB.Preload("Product").Preload("Product.Category").Preload("User", unscoped).Preload("Location").Preload("ReasonCode").First(&movement, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Movimiento no encontrado"})
 		return
 	}
@@ -40,15 +79,49 @@ func GetMovement(c *gin.Context) {
 	})
 }
 
-// POST /api/movements - Crear nuevo movimiento (entrada o salida)
-func CreateMovement(c *gin.Context) {
-	var movement models.Movement
+// CreateMovementRequest son los campos que el cliente puede informar al registrar un
+// movimiento. El ID, el usuario y la fecha los asigna el servidor: el historial solo admite altas.
+// Reversos, transferencias, recepciones y despachos se registran desde sus propios endpoints.
+type CreateMovementRequest struct {
+	ProductID    uint     `json:"product_id"`
+	Barcode      string   `json:"barcode"` // SKU o código de barras leído por el escáner
+	Type         string   `json:"type"`
+	Quantity     int      `json:"quantity"`
+	Description  string   `json:"description"`
+	ReasonCodeID *uint    `json:"reason_code_id"`
+	LocationID   *uint    `json:"location_id"`
+	UnitCost     *float64 `json:"unit_cost"`
+}
 
-	if err := c.ShouldBindJSON(&movement); err != nil {
+// POST /api/movements - Crear nuevo movimiento (entrada, salida, ajuste, baja o devolución)
+func CreateMovement(c *gin.Context) {
+	var req CreateMovementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	movement := models.Movement{
+		ProductID:    req.ProductID,
+		Type:         req.Type,
+		Quantity:     req.Quantity,
+		Description:  req.Description,
+		ReasonCodeID: req.ReasonCodeID,
+		LocationID:   req.LocationID,
+		UnitCost:     req.UnitCost,
+		MovementDate: time.Now(),
+	}
+
+	// Se acepta product_id o barcode
+	if movement.ProductID == 0 && req.Barcode != "" {
+		product, err := resolveProductCode(config.DB, req.Barcode)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No hay un producto con ese código"})
+			return
+		}
+		movement.ProductID = product.ID
+	}
+
 	// Obtener user_id del contexto (del token JWT)
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -59,72 +132,67 @@ func CreateMovement(c *gin.Context) {
 
 	// Validaciones
 	if movement.ProductID == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "El product_id es requerido"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El product_id o el barcode es requerido"})
 		return
 	}
 
-	if movement.Type != "entrada" && movement.Type != "salida" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "El tipo debe ser 'entrada' o 'salida'"})
+	if !models.IsManualType(movement.Type) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":         "Tipo de movimiento inválido",
+			"allowed_types": models.ManualMovementTypes,
+		})
 		return
 	}
 
+	// Ajustes, bajas y devoluciones requieren un motivo activo del catálogo
+	if models.RequiresReasonCode(movement.Type) {
+		if movement.ReasonCodeID == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El reason_code_id es requerido para este tipo de movimiento"})
+			return
+		}
+		var reason models.ReasonCode
+		if err := config.DB.Where("id = ? AND active = ?", *movement.ReasonCodeID, true).First(&reason).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El motivo especificado no existe o está inactivo"})
+			return
+		}
+		if !reason.AllowsType(movement.Type) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El motivo no aplica a este tipo de movimiento"})
+			return
+		}
+	} else {
+		movement.ReasonCodeID = nil
+	}
+
 	if movement.Quantity <= 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "La cantidad debe ser mayor a 0"})
 		return
 	}
 
-	// Verificar que el producto existe
-	var product models.Product
-	if err := config.DB.First(&product, movement.ProductID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+	// El costo unitario solo se informa en los ingresos; las salidas se valúan con el costo del stock
+	if models.MovementSign(movement.Type) < 0 {
+		movement.UnitCost = nil
+	} else if movement.UnitCost != nil && *movement.UnitCost < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El costo unitario no puede ser negativo"})
 		return
 	}
 
-	// Validar stock suficiente para salidas
-	if movement.Type == "salida" {
-		if product.Stock < movement.Quantity {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error":           "Stock insuficiente",
-				"stock_actual":    product.Stock,
-				"cantidad_salida": movement.Quantity,
-			})
-			return
-		}
-	}
-
-	// Establecer fecha actual si no se proporcionó
-	if movement.MovementDate.IsZero() {
-		movement.MovementDate = time.Now()
-	}
-
-	// Iniciar transacción para garantizar consistencia
+	// Validar stock, crear el movimiento y actualizar el stock de forma atómica
 	tx := config.DB.Begin()
 
-	// Crear el movimiento
-	if err := tx.Create(&movement).Error; err != nil {
+	product, err := applyMovement(tx, &movement)
+	if err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear movimiento"})
+		respondMovementError(c, err)
 		return
 	}
 
-	// Actualizar stock del producto
-	if movement.Type == "entrada" {
-		product.Stock += movement.Quantity
-	} else { // salida
-		product.Stock -= movement.Quantity
-	}
-
-	if err := tx.Save(&product).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar stock"})
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear movimiento"})
 		return
 	}
 
-	// Confirmar transacción
-	tx.Commit()
-
 	// Cargar relaciones para la respuesta
-	config.DB.Preload("Product").Preload("Product.Category").Preload("User").First(&movement, movement.ID)
+	config.DB.Preload("Product").Preload("Product.Category").Preload("User", unscoped).Preload("Location").Preload("ReasonCode").First(&movement, movement.ID)
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message":     "Movimiento registrado exitosamente",
@@ -133,64 +201,152 @@ func CreateMovement(c *gin.Context) {
 	})
 }
 
-// GET /api/movements/product/:product_id - Movimientos de un producto específico
+// GET /api/movements/product/:product_id - Movimientos de un producto específico (paginado)
 func GetMovementsByProduct(c *gin.Context) {
 	productID := c.Param("product_id")
-	var movements []models.Movement
+	listMovements(c, config.DB.Model(&models.Movement{}).Where("movements.product_id = ?", productID), nil)
+}
 
-	if err := config.DB.Preload("User").Where("product_id = ?", productID).Order("movement_date DESC").Find(&movements).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener movimientos"})
+// GET /api/movements/type/:type - Movimientos por tipo (entrada, salida, transferencia_salida...) (paginado)
+func GetMovementsByType(c *gin.Context) {
+	movementType := c.Param("type")
+
+	if models.MovementSign(movementType) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tipo de movimiento inválido"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"movements": movements,
-		"total":     len(movements),
-	})
+	listMovements(c, config.DB.Model(&models.Movement{}).Where("movements.type = ?", movementType), gin.H{"type": movementType})
 }
 
-// GET /api/movements/type/:type - Movimientos por tipo (entrada/salida)
-func GetMovementsByType(c *gin.Context) {
-	movementType := c.Param("type")
+// alreadyReversed indica si el movimiento ya tiene un reverso registrado
+func alreadyReversed(db *gorm.DB, movementID uint) bool {
+	var count int64
+	db.Model(&models.Movement{}).Where("reversal_of_id = ?", movementID).Count(&count)
+	return count > 0
+}
+
+// POST /api/movements/:id/reverse - Revertir un movimiento con un movimiento compensatorio (solo admin)
+func ReverseMovement(c *gin.Context) {
+	id := c.Param("id")
+	var original models.Movement
 
-	if movementType != "entrada" && movementType != "salida" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Tipo inválido. Use 'entrada' o 'salida'"})
+	if err := config.DB.First(&original, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movimiento no encontrado"})
 		return
 	}
 
-	var movements []models.Movement
+	// Los reversos no se pueden revertir; se registra un nuevo movimiento en su lugar
+	if models.IsReversal(original.Type) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No se puede revertir un movimiento de reverso"})
+		return
+	}
 
-	if err := config.DB.Preload("Product").Preload("Product.Category").Preload("User").Where("type = ?", movementType).Order("movement_date DESC").Find(&movements).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener movimientos"})
+	// Los tramos de una transferencia se anulan cancelando la transferencia
+	if original.TransferID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Los movimientos de transferencia no se pueden revertir individualmente"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"movements": movements,
-		"total":     len(movements),
-		"type":      movementType,
-	})
-}
+	// Verificar que el movimiento no haya sido revertido antes (se repite dentro de la transacción)
+	if alreadyReversed(config.DB, original.ID) {
+		c.JSON(http.StatusConflict, gin.H{"error": "El movimiento ya fue revertido"})
+		return
+	}
 
-// DELETE /api/movements/:id - Eliminar movimiento (solo admin, no revierte stock)
-func DeleteMovement(c *gin.Context) {
-	id := c.Param("id")
-	var movement models.Movement
+	// El motivo es opcional
+	var req struct {
+		Description string `json:"description"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.Description == "" {
+		req.Description = fmt.Sprintf("Reverso del movimiento #%d", original.ID)
+	}
 
-	if err := config.DB.First(&movement, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Movimiento no encontrado"})
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
 		return
 	}
 
-	// NOTA: Este delete NO revierte el stock automáticamente
-	// Si quieres revertir el stock, deberías hacerlo manualmente
-	if err := config.DB.Delete(&movement).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar movimiento"})
+	reversal := models.Movement{
+		ProductID:    original.ProductID,
+		UserID:       userID.(uint),
+		Type:         models.ReversalType(original.Type),
+		Quantity:     original.Quantity,
+		Description:  req.Description,
+		LocationID:   original.LocationID,
+		ReversalOfID: &original.ID,
+		MovementDate: time.Now(),
+	}
+
+	// El reverso y la actualización de stock van en la misma transacción
+	tx := config.DB.Begin()
+
+	// Con el producto bloqueado, un segundo reverso concurrente espera y ve el primero
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.Product{}, original.ProductID).Error; err != nil {
+		tx.Rollback()
+		respondMovementError(c, errProductNotFound)
+		return
+	}
+	if alreadyReversed(tx, original.ID) {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": "El movimiento ya fue revertido"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Movimiento eliminado exitosamente",
-		"warning": "El stock del producto NO fue revertido automáticamente",
+	product, err := applyMovement(tx, &reversal)
+	if err != nil {
+		tx.Rollback()
+		if isDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "El movimiento ya fue revertido"})
+			return
+		}
+		var stockErr *insufficientStockError
+		if errors.As(err, &stockErr) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":        "Stock insuficiente para revertir el movimiento",
+				"stock_actual": stockErr.Stock,
+			})
+			return
+		}
+		respondMovementError(c, err)
+		return
+	}
+
+	// Revertir una recepción de compra vuelve a dejar pendiente esa cantidad en la orden
+	if original.PurchaseOrderLineID != nil {
+		if err := unreceivePurchaseOrderLine(tx, *original.PurchaseOrderLineID, original.Quantity); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al revertir movimiento"})
+			return
+		}
+	}
+
+	// Revertir un despacho vuelve a dejar pendiente (y reservada) esa cantidad en el pedido
+	if original.SalesOrderLineID != nil {
+		if err := unfulfillSalesOrderLine(tx, *original.SalesOrderLineID, original.Quantity); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al revertir movimiento"})
+			return
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al revertir movimiento"})
+		return
+	}
+
+	config.DB.Preload("Product").Preload("Product.Category").Preload("User", unscoped).Preload("Location").Preload("ReasonCode").First(&reversal, reversal.ID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":     "Movimiento revertido exitosamente",
+		"movement":    reversal,
+		"nuevo_stock": product.Stock,
 	})
 }
@@ -1,26 +1,65 @@
 package controllers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/Stormdead/inventory-control-panel/backend/config"
 	"github.com/Stormdead/inventory-control-panel/backend/models"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-// GET /api/products - Listar todos los productos
+// GET /api/products - Listar productos con paginación por cursor, filtros y orden
+// Filtros: search, category_id, min_stock, max_stock, min_price, max_price. Orden: sort=name, -price...
 func GetProducts(c *gin.Context) {
+	listProducts(c, config.DB.Model(&models.Product{}))
+}
+
+// listProducts pagina y filtra los productos de la consulta base
+func listProducts(c *gin.Context, base *gorm.DB) {
+	q, err := parseListQuery(c, productSorts, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query, err := filterProducts(c, base)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener productos"})
+		return
+	}
+
 	var products []models.Product
 
 	// Incluir la relación con Category
-	if err := config.DB.Preload("Category").Find(&products).Error; err != nil {
+	if err := q.apply(query.Preload("Category"), "products.id").Find(&products).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener productos"})
 		return
 	}
 
+	products, nextCursor := paginate(products, q, productSortValue)
+	page := make([]*models.Product, len(products))
+	for i := range products {
+		page[i] = &products[i]
+	}
+	fillOrderQuantities(page...)
+
 	c.JSON(http.StatusOK, gin.H{
-		"products": products,
-		"total":    len(products),
+		"products":    products,
+		"total":       total,
+		"next_cursor": nextCursor,
 	})
 }
 
@@ -29,16 +68,113 @@ func GetProduct(c *gin.Context) {
 	id := c.Param("id")
 	var product models.Product
 
-	if err := config.DB.Preload("Category").First(&product, id).Error; err != nil {
+	if err := config.DB.Preload("Category").Preload("Barcodes").Preload("Stocks.Location").First(&product, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
 		return
 	}
+	fillOrderQuantities(&product)
 
 	c.JSON(http.StatusOK, gin.H{
 		"product": product,
 	})
 }
 
+// errDuplicateSKU se devuelve cuando el SKU ya pertenece a otro producto
+var errDuplicateSKU = errors.New("SKU duplicado")
+
+// validateProduct aplica las reglas de un producto nuevo: nombre requerido, precio
+// mayor a 0, categoría existente, stock inicial no negativo y SKU/códigos únicos.
+// La usan CreateProduct y la importación masiva.
+func validateProduct(db *gorm.DB, product *models.Product) error {
+	if product.Name == "" {
+		return errors.New("El nombre es requerido")
+	}
+
+	if product.Price <= 0 {
+		return errors.New("El precio debe ser mayor a 0")
+	}
+
+	// Verificar que la categoría existe (si se proporcionó)
+	if product.CategoryID != nil {
+		var category models.Category
+		if err := db.First(&category, *product.CategoryID).Error; err != nil {
+			return errors.New("La categoría especificada no existe")
+		}
+	}
+
+	if product.Stock < 0 {
+		return errors.New("El stock inicial no puede ser negativo")
+	}
+
+	if product.AverageCost < 0 {
+		return errors.New("El costo no puede ser negativo")
+	}
+
+	if err := validateStockLevels(product.MinStock, product.ReorderPoint, product.MaxStock); err != nil {
+		return err
+	}
+
+	// SKU y códigos de barras únicos y con dígito de control válido
+	product.SKU = normalizeSKU(product.SKU)
+	if err := validateSKU(db, product.SKU, 0); err != nil {
+		return fmt.Errorf("%w: %v", errDuplicateSKU, err)
+	}
+	if err := validateBarcodes(db, product.Barcodes, 0); err != nil {
+		return err
+	}
+	product.Stocks = nil
+
+	return nil
+}
+
+// fillOrderQuantities completa lo pendiente en órdenes de compra, lo reservado por
+// pedidos de clientes y el disponible para prometer de cada producto
+func fillOrderQuantities(products ...*models.Product) {
+	ids := make([]uint, len(products))
+	for i, p := range products {
+		ids[i] = p.ID
+	}
+
+	onOrder := onOrderQuantities(config.DB, ids)
+	reserved := reservedQuantities(config.DB, ids)
+	for _, p := range products {
+		p.OnOrder = onOrder[p.ID]
+		p.Reserved = reserved[p.ID]
+		p.Available = p.Stock - p.Reserved
+	}
+}
+
+// createProduct crea el producto dentro de tx y registra su stock inicial como movimiento
+func createProduct(tx *gorm.DB, product *models.Product, userID uint) error {
+	// average_cost es el costo unitario del stock inicial
+	initialStock, initialCost := product.Stock, product.AverageCost
+	product.Stock = 0
+
+	if err := tx.Create(product).Error; err != nil {
+		return err
+	}
+
+	if initialStock > 0 {
+		initial := models.Movement{
+			ProductID:    product.ID,
+			UserID:       userID,
+			Type:         models.MovementTypeAjusteEntrada,
+			Quantity:     initialStock,
+			Description:  "Stock inicial",
+			ReasonCodeID: systemReasonCodeID(tx, models.ReasonCodeStockInicial),
+			UnitCost:     &initialCost,
+			MovementDate: time.Now(),
+		}
+		stocked, err := applyMovement(tx, &initial)
+		if err != nil {
+			return err
+		}
+		product.Stock, product.AverageCost = stocked.Stock, stocked.AverageCost
+	}
+
+	return nil
+}
+
 // POST /api/products - Crear nuevo producto
 func CreateProduct(c *gin.Context) {
 	var product models.Product
@@ -49,33 +185,34 @@ func CreateProduct(c *gin.Context) {
 	}
 
 	// Validaciones
-	if product.Name == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "El nombre es requerido"})
+	if err := validateProduct(config.DB, &product); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, errDuplicateSKU) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
-	if product.Price <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "El precio debe ser mayor a 0"})
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
 		return
 	}
 
-	// Verificar que la categoría existe (si se proporcionó)
-	if product.CategoryID != nil {
-		var category models.Category
-		if err := config.DB.First(&category, *product.CategoryID).Error; err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "La categoría especificada no existe"})
-			return
-		}
-	}
+	// Crear producto y registrar el stock inicial como movimiento en la misma transacción
+	tx := config.DB.Begin()
 
-	// Crear producto
-	if err := config.DB.Create(&product).Error; err != nil {
+	if err := createProduct(tx, &product, userID.(uint)); err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear producto"})
 		return
 	}
 
+	tx.Commit()
+
 	// Cargar la categoría para la respuesta
-	config.DB.Preload("Category").First(&product, product.ID)
+	config.DB.Preload("Category").Preload("Barcodes").First(&product, product.ID)
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Producto creado exitosamente",
@@ -83,7 +220,24 @@ func CreateProduct(c *gin.Context) {
 	})
 }
 
-// PUT /api/products/:id - Actualizar producto
+// ProductPatch son los campos editables de un producto. Solo se modifican los presentes
+// en el cuerpo; null borra los campos opcionales (sku, category_id y niveles de stock).
+type ProductPatch struct {
+	Name         *string  `json:"name"`
+	SKU          *string  `json:"sku"`
+	Description  *string  `json:"description"`
+	CategoryID   *uint    `json:"category_id"`
+	Price        *float64 `json:"price"`
+	ImageURL     *string  `json:"image_url"`
+	MinStock     *int     `json:"min_stock"`
+	MaxStock     *int     `json:"max_stock"`
+	ReorderPoint *int     `json:"reorder_point"`
+	Stock        *int     `json:"stock"`
+	AverageCost  *float64 `json:"average_cost"`
+}
+
+// PATCH /api/products/:id - Actualizar solo los campos enviados (PUT se acepta como alias).
+// El stock y el costo solo cambian con movimientos; cambiar el precio requiere products:price.
 func UpdateProduct(c *gin.Context) {
 	id := c.Param("id")
 	var product models.Product
@@ -94,44 +248,119 @@ func UpdateProduct(c *gin.Context) {
 		return
 	}
 
-	// Obtener datos de actualización
-	var updateData models.Product
-	if err := c.ShouldBindJSON(&updateData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	// Se decodifica dos veces: los valores y qué campos vinieron en el cuerpo
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No se pudo leer el cuerpo de la petición"})
+		return
+	}
+	var present map[string]json.RawMessage
+	var patch ProductPatch
+	if err := json.Unmarshal(body, &present); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "JSON inválido: " + err.Error()})
 		return
 	}
+	if err := json.Unmarshal(body, &patch); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "JSON inválido: " + err.Error()})
+		return
+	}
+	has := func(field string) bool {
+		_, ok := present[field]
+		return ok
+	}
 
-	// Actualizar campos
-	if updateData.Name != "" {
-		product.Name = updateData.Name
+	// Los campos calculados se aceptan sin cambios (clientes que reenvían el producto
+	// completo), pero nunca se pueden fijar directamente
+	if has("stock") && (patch.Stock == nil || *patch.Stock != product.Stock) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El stock solo se modifica registrando movimientos"})
+		return
+	}
+	if has("average_cost") && (patch.AverageCost == nil || *patch.AverageCost != product.AverageCost) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El costo promedio se calcula a partir de los movimientos"})
+		return
 	}
-	if updateData.Description != "" {
-		product.Description = updateData.Description
+
+	updates := map[string]interface{}{}
+
+	if has("name") {
+		if patch.Name == nil || strings.TrimSpace(*patch.Name) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El nombre es requerido"})
+			return
+		}
+		updates["name"] = strings.TrimSpace(*patch.Name)
 	}
-	if updateData.Price > 0 {
-		product.Price = updateData.Price
+	if has("description") {
+		updates["description"] = ""
+		if patch.Description != nil {
+			updates["description"] = *patch.Description
+		}
 	}
-	if updateData.Stock >= 0 {
-		product.Stock = updateData.Stock
+	if has("image_url") {
+		updates["image_url"] = ""
+		if patch.ImageURL != nil {
+			updates["image_url"] = *patch.ImageURL
+		}
 	}
-	if updateData.ImageURL != "" {
-		product.ImageURL = updateData.ImageURL
+	if has("price") {
+		if patch.Price == nil || *patch.Price <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El precio debe ser mayor a 0"})
+			return
+		}
+		if *patch.Price != product.Price {
+			if !callerRole(c).HasPermission(models.PermissionProductsPrice) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Cambiar el precio requiere el permiso " + models.PermissionProductsPrice})
+				return
+			}
+			updates["price"] = *patch.Price
+		}
 	}
-	if updateData.CategoryID != nil {
-		// Verificar que la categoría existe
-		var category models.Category
-		if err := config.DB.First(&category, *updateData.CategoryID).Error; err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "La categoría especificada no existe"})
+	if has("sku") {
+		sku := normalizeSKU(patch.SKU)
+		if err := validateSKU(config.DB, sku, product.ID); err != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 			return
 		}
-		product.CategoryID = updateData.CategoryID
+		updates["sku"] = sku
+	}
+	if has("category_id") {
+		if patch.CategoryID != nil {
+			// Verificar que la categoría existe
+			var category models.Category
+			if err := config.DB.First(&category, *patch.CategoryID).Error; err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "La categoría especificada no existe"})
+				return
+			}
+		}
+		updates["category_id"] = patch.CategoryID
 	}
 
-	if err := config.DB.Save(&product).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar producto"})
+	// Los niveles se validan combinando los enviados con los actuales
+	minStock, maxStock, reorderPoint := product.MinStock, product.MaxStock, product.ReorderPoint
+	if has("min_stock") {
+		minStock = patch.MinStock
+		updates["min_stock"] = patch.MinStock
+	}
+	if has("max_stock") {
+		maxStock = patch.MaxStock
+		updates["max_stock"] = patch.MaxStock
+	}
+	if has("reorder_point") {
+		reorderPoint = patch.ReorderPoint
+		updates["reorder_point"] = patch.ReorderPoint
+	}
+	if err := validateStockLevels(minStock, reorderPoint, maxStock); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	// Solo se escriben las columnas enviadas: un movimiento simultáneo no pierde su stock
+	if len(updates) > 0 {
+		if err := config.DB.Model(&product).Updates(updates).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar producto"})
+			return
+		}
+	}
+
 	// Cargar la categoría para la respuesta
 	config.DB.Preload("Category").First(&product, product.ID)
 
@@ -163,11 +392,11 @@ func DeleteProduct(c *gin.Context) {
 	})
 }
 
-// GET /api/products/low-stock - Productos con stock bajo (menos de 10 unidades)
+// GET /api/products/low-stock - Productos con stock por debajo de su punto de pedido
 func GetLowStockProducts(c *gin.Context) {
 	var products []models.Product
 
-	if err := config.DB.Preload("Category").Where("stock < ?", 10).Find(&products).Error; err != nil {
+	if err := config.DB.Preload("Category").Where("products.stock < " + reorderPointSQL("products")).Find(&products).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener productos"})
 		return
 	}
@@ -178,18 +407,8 @@ func GetLowStockProducts(c *gin.Context) {
 	})
 }
 
-// GET /api/products/category/:category_id - Productos por categoría
+// GET /api/products/category/:category_id - Productos por categoría (paginado)
 func GetProductsByCategory(c *gin.Context) {
 	categoryID := c.Param("category_id")
-	var products []models.Product
-
-	if err := config.DB.Preload("Category").Where("category_id = ?", categoryID).Find(&products).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener productos"})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"products": products,
-		"total":    len(products),
-	})
+	listProducts(c, config.DB.Model(&models.Product{}).Where("products.category_id = ?", categoryID))
 }
@@ -0,0 +1,409 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Stormdead/inventory-control-panel/backend/config"
+	"github.com/Stormdead/inventory-control-panel/backend/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PurchaseOrderLineRequest struct {
+	ProductID    uint    `json:"product_id" binding:"required"`
+	Quantity     int     `json:"quantity" binding:"required,gt=0"`
+	UnitCost     float64 `json:"unit_cost" binding:"gte=0"`
+	ExpectedDate string  `json:"expected_date"`
+}
+
+type PurchaseOrderRequest struct {
+	SupplierID   uint                       `json:"supplier_id" binding:"required"`
+	LocationID   *uint                      `json:"location_id"` // Por defecto la ubicación principal
+	Reference    string                     `json:"reference"`
+	ExpectedDate string                     `json:"expected_date"`
+	Notes        string                     `json:"notes"`
+	Lines        []PurchaseOrderLineRequest `json:"lines" binding:"required,min=1,dive"`
+}
+
+type ReceivePurchaseOrderRequest struct {
+	Lines []struct {
+		LineID   uint `json:"line_id" binding:"required"`
+		Quantity int  `json:"quantity" binding:"required,gt=0"`
+	} `json:"lines" binding:"required,min=1,dive"`
+	Description string `json:"description"`
+}
+
+// parseOptionalDate convierte una fecha opcional del request; vacía devuelve nil
+func parseOptionalDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := parseDateParam(value, false)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// loadPurchaseOrder carga una orden de compra con sus relaciones para la respuesta
+func loadPurchaseOrder(order *models.PurchaseOrder) {
+	config.DB.Preload("Supplier").Preload("Location").
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Lines.Product").
+		First(order, order.ID)
+}
+
+// purchaseOrderStatus calcula el estado de una orden abierta según lo recibido en sus líneas
+func purchaseOrderStatus(lines []models.PurchaseOrderLine) string {
+	pending, received := 0, 0
+	for _, line := range lines {
+		pending += line.Pending()
+		received += line.ReceivedQuantity
+	}
+	switch {
+	case pending == 0:
+		return models.PurchaseOrderStatusRecibida
+	case received > 0:
+		return models.PurchaseOrderStatusParcial
+	}
+	return models.PurchaseOrderStatusAbierta
+}
+
+// onOrderQuantities devuelve, por producto, lo pendiente de recibir en órdenes de compra abiertas
+func onOrderQuantities(db *gorm.DB, productIDs []uint) map[uint]int {
+	result := map[uint]int{}
+	if len(productIDs) == 0 {
+		return result
+	}
+
+	var rows []struct {
+		ProductID uint
+		OnOrder   int
+	}
+	db.Table("purchase_order_lines as l").
+		Select("l.product_id, SUM(l.quantity - l.received_quantity) as on_order").
+		Joins("JOIN purchase_orders po ON po.id = l.purchase_order_id").
+		Where("po.status IN ? AND l.product_id IN ? AND l.quantity > l.received_quantity",
+			models.OpenPurchaseOrderStatuses, productIDs).
+		Group("l.product_id").
+		Scan(&rows)
+
+	for _, row := range rows {
+		result[row.ProductID] = row.OnOrder
+	}
+	return result
+}
+
+// unreceivePurchaseOrderLine descuenta de una línea lo recibido por un movimiento revertido
+// y reabre la orden si estaba completa
+func unreceivePurchaseOrderLine(tx *gorm.DB, lineID uint, quantity int) error {
+	var line models.PurchaseOrderLine
+	if err := tx.First(&line, lineID).Error; err != nil {
+		return err
+	}
+
+	var order models.PurchaseOrder
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Lines").First(&order, line.PurchaseOrderID).Error; err != nil {
+		return err
+	}
+
+	received := line.ReceivedQuantity - quantity
+	if received < 0 {
+		received = 0
+	}
+	if err := tx.Model(&line).Update("received_quantity", received).Error; err != nil {
+		return err
+	}
+
+	if order.Status == models.PurchaseOrderStatusCancelada {
+		return nil
+	}
+	for i := range order.Lines {
+		if order.Lines[i].ID == line.ID {
+			order.Lines[i].ReceivedQuantity = received
+		}
+	}
+	return tx.Model(&order).Update("status", purchaseOrderStatus(order.Lines)).Error
+}
+
+// GET /api/purchase-orders - Listar órdenes de compra (?status=, ?supplier_id=, ?open=true)
+func GetPurchaseOrders(c *gin.Context) {
+	var orders []models.PurchaseOrder
+
+	query := config.DB.Preload("Supplier").Preload("Location").Preload("Lines")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if c.Query("open") == "true" {
+		query = query.Where("status IN ?", models.OpenPurchaseOrderStatuses)
+	}
+	if supplierID := c.Query("supplier_id"); supplierID != "" {
+		query = query.Where("supplier_id = ?", supplierID)
+	}
+
+	if err := query.Order("created_at DESC").Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener órdenes de compra"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"purchase_orders": orders,
+		"total":           len(orders),
+	})
+}
+
+// GET /api/purchase-orders/:id - Obtener una orden de compra con sus líneas y recepciones
+func GetPurchaseOrder(c *gin.Context) {
+	id := c.Param("id")
+	var order models.PurchaseOrder
+
+	if err := config.DB.First(&order, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Orden de compra no encontrada"})
+		return
+	}
+
+	loadPurchaseOrder(&order)
+
+	var receipts []models.Movement
+	config.DB.Preload("User", unscoped).
+		Where("purchase_order_line_id IN (?)",
+			config.DB.Model(&models.PurchaseOrderLine{}).Select("id").Where("purchase_order_id = ?", order.ID)).
+		Order("id").
+		Find(&receipts)
+
+	c.JSON(http.StatusOK, gin.H{
+		"purchase_order": order,
+		"receipts":       receipts,
+	})
+}
+
+// POST /api/purchase-orders - Crear una orden de compra (solo admin)
+func CreatePurchaseOrder(c *gin.Context) {
+	var req PurchaseOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var supplier models.Supplier
+	if err := config.DB.Where("id = ? AND active = ?", req.SupplierID, true).First(&supplier).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El proveedor no existe o está inactivo"})
+		return
+	}
+
+	locationID, err := resolveLocationID(config.DB, req.LocationID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La ubicación especificada no existe"})
+		return
+	}
+
+	expectedDate, err := parseOptionalDate(req.ExpectedDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	order := models.PurchaseOrder{
+		SupplierID:   supplier.ID,
+		LocationID:   locationID,
+		Status:       models.PurchaseOrderStatusAbierta,
+		Reference:    req.Reference,
+		ExpectedDate: expectedDate,
+		Notes:        req.Notes,
+		CreatedByID:  userID.(uint),
+	}
+
+	for i, line := range req.Lines {
+		var count int64
+		config.DB.Model(&models.Product{}).Where("id = ?", line.ProductID).Count(&count)
+		if count == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Línea %d: el producto no existe", i+1)})
+			return
+		}
+
+		lineDate, err := parseOptionalDate(line.ExpectedDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Línea %d: %s", i+1, err.Error())})
+			return
+		}
+
+		order.Lines = append(order.Lines, models.PurchaseOrderLine{
+			ProductID:    line.ProductID,
+			Quantity:     line.Quantity,
+			UnitCost:     line.UnitCost,
+			ExpectedDate: lineDate,
+		})
+	}
+
+	// La orden y sus líneas se crean juntas
+	if err := config.DB.Create(&order).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear la orden de compra"})
+		return
+	}
+
+	loadPurchaseOrder(&order)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":        "Orden de compra creada exitosamente",
+		"purchase_order": order,
+	})
+}
+
+// POST /api/purchase-orders/:id/receive - Recibir mercadería de una orden, total o parcialmente.
+// Cada línea recibida genera un movimiento de entrada al costo de la orden en la ubicación de destino.
+func ReceivePurchaseOrder(c *gin.Context) {
+	var req ReceivePurchaseOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	tx := config.DB.Begin()
+
+	// Bloquear la orden para que dos recepciones simultáneas no superen lo pedido
+	var order models.PurchaseOrder
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Lines").First(&order, c.Param("id")).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Orden de compra no encontrada"})
+		return
+	}
+
+	if order.Status != models.PurchaseOrderStatusAbierta && order.Status != models.PurchaseOrderStatusParcial {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": "La orden de compra no está abierta", "status": order.Status})
+		return
+	}
+
+	description := req.Description
+	if description == "" {
+		description = fmt.Sprintf("Recepción de la orden de compra #%d", order.ID)
+	}
+
+	var receipts []models.Movement
+	for _, item := range req.Lines {
+		var line *models.PurchaseOrderLine
+		for i := range order.Lines {
+			if order.Lines[i].ID == item.LineID {
+				line = &order.Lines[i]
+			}
+		}
+		if line == nil {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("La línea %d no pertenece a la orden", item.LineID)})
+			return
+		}
+
+		if item.Quantity > line.Pending() {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":     "La cantidad recibida supera lo pendiente de la línea",
+				"line_id":   line.ID,
+				"pendiente": line.Pending(),
+			})
+			return
+		}
+
+		unitCost := line.UnitCost
+		receipt := models.Movement{
+			ProductID:           line.ProductID,
+			UserID:              userID.(uint),
+			LocationID:          &order.LocationID,
+			Type:                models.MovementTypeEntrada,
+			Quantity:            item.Quantity,
+			Description:         description,
+			UnitCost:            &unitCost,
+			PurchaseOrderLineID: &line.ID,
+			MovementDate:        time.Now(),
+		}
+		if _, err := applyMovement(tx, &receipt); err != nil {
+			tx.Rollback()
+			respondMovementError(c, err)
+			return
+		}
+
+		line.ReceivedQuantity += item.Quantity
+		if err := tx.Model(line).Update("received_quantity", line.ReceivedQuantity).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al registrar la recepción"})
+			return
+		}
+		receipts = append(receipts, receipt)
+	}
+
+	order.Status = purchaseOrderStatus(order.Lines)
+	if err := tx.Model(&order).Update("status", order.Status).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al registrar la recepción"})
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al registrar la recepción"})
+		return
+	}
+
+	loadPurchaseOrder(&order)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Recepción registrada exitosamente",
+		"purchase_order": order,
+		"receipts":       receipts,
+	})
+}
+
+// POST /api/purchase-orders/:id/cancel - Cancelar lo pendiente de una orden de compra (solo admin).
+// Lo ya recibido queda registrado; solo deja de contarse lo pendiente como "en pedido".
+func CancelPurchaseOrder(c *gin.Context) {
+	tx := config.DB.Begin()
+
+	var order models.PurchaseOrder
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, c.Param("id")).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Orden de compra no encontrada"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al cancelar la orden de compra"})
+		return
+	}
+
+	if order.Status != models.PurchaseOrderStatusAbierta && order.Status != models.PurchaseOrderStatusParcial {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": "La orden de compra no está abierta", "status": order.Status})
+		return
+	}
+
+	if err := tx.Model(&order).Update("status", models.PurchaseOrderStatusCancelada).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al cancelar la orden de compra"})
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al cancelar la orden de compra"})
+		return
+	}
+
+	loadPurchaseOrder(&order)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Orden de compra cancelada exitosamente",
+		"purchase_order": order,
+	})
+}
@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Stormdead/inventory-control-panel/backend/config"
+	"github.com/Stormdead/inventory-control-panel/backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// validateReasonCodeTypes verifica que los tipos del motivo requieran motivo
+func validateReasonCodeTypes(movementTypes string) bool {
+	if strings.TrimSpace(movementTypes) == "" {
+		return true
+	}
+	for _, t := range strings.Split(movementTypes, ",") {
+		if !models.RequiresReasonCode(strings.TrimSpace(t)) {
+			return false
+		}
+	}
+	return true
+}
+
+// GET /api/reason-codes - Listar motivos (?active=true para solo activos)
+func GetReasonCodes(c *gin.Context) {
+	var reasons []models.ReasonCode
+
+	query := config.DB.Order("code")
+	if c.Query("active") == "true" {
+		query = query.Where("active = ?", true)
+	}
+
+	if err := query.Find(&reasons).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener motivos"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reason_codes": reasons,
+		"total":        len(reasons),
+	})
+}
+
+// POST /api/reason-codes - Crear un motivo (solo admin)
+func CreateReasonCode(c *gin.Context) {
+	var reason models.ReasonCode
+
+	if err := c.ShouldBindJSON(&reason); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reason.Code = strings.ToUpper(strings.TrimSpace(reason.Code))
+	if reason.Code == "" || reason.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El código y el nombre son requeridos"})
+		return
+	}
+
+	if !validateReasonCodeTypes(reason.MovementTypes) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "movement_types solo admite ajustes, bajas y devoluciones"})
+		return
+	}
+
+	var existing models.ReasonCode
+	if err := config.DB.Where("code = ?", reason.Code).First(&existing).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Ya existe un motivo con ese código"})
+		return
+	}
+
+	reason.Active = true
+	if err := config.DB.Create(&reason).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear motivo"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":     "Motivo creado exitosamente",
+		"reason_code": reason,
+	})
+}
+
+// PUT /api/reason-codes/:id - Actualizar un motivo (solo admin)
+func UpdateReasonCode(c *gin.Context) {
+	id := c.Param("id")
+	var reason models.ReasonCode
+
+	if err := config.DB.First(&reason, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Motivo no encontrado"})
+		return
+	}
+
+	var updateData struct {
+		Name          string  `json:"name"`
+		Description   *string `json:"description"`
+		MovementTypes *string `json:"movement_types"`
+		Active        *bool   `json:"active"`
+	}
+	if err := c.ShouldBindJSON(&updateData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if updateData.Name != "" {
+		reason.Name = updateData.Name
+	}
+	if updateData.Description != nil {
+		reason.Description = *updateData.Description
+	}
+	if updateData.MovementTypes != nil {
+		if !validateReasonCodeTypes(*updateData.MovementTypes) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "movement_types solo admite ajustes, bajas y devoluciones"})
+			return
+		}
+		reason.MovementTypes = *updateData.MovementTypes
+	}
+	if updateData.Active != nil {
+		reason.Active = *updateData.Active
+	}
+
+	if err := config.DB.Save(&reason).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar motivo"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Motivo actualizado exitosamente",
+		"reason_code": reason,
+	})
+}
@@ -0,0 +1,275 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/Stormdead/inventory-control-panel/backend/config"
+	"github.com/Stormdead/inventory-control-panel/backend/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StockDiscrepancy describe un producto cuyo stock en una ubicación no coincide con sus movimientos
+type StockDiscrepancy struct {
+	ProductID            uint   `json:"product_id"`
+	ProductName          string `json:"product_name"`
+	LocationID           uint   `json:"location_id"`
+	LocationName         string `json:"location_name"`
+	StoredStock          int    `json:"stored_stock"`
+	LedgerStock          int    `json:"ledger_stock"`
+	Difference           int    `json:"difference"`
+	AdjustmentMovementID *uint  `json:"adjustment_movement_id,omitempty"`
+}
+
+// stockKey identifica el stock de un producto en una ubicación
+type stockKey struct {
+	ProductID  uint
+	LocationID uint
+}
+
+// FindStockDiscrepancies compara el stock guardado de cada producto en cada ubicación
+// con el calculado desde los movimientos
+func FindStockDiscrepancies(db *gorm.DB) ([]StockDiscrepancy, error) {
+	return findStockDiscrepancies(db, 0)
+}
+
+// findStockDiscrepancies calcula las diferencias de un producto (o de todos si productID es 0).
+// El stock guardado es el de product_stocks; si products.stock no coincide con la suma de
+// las ubicaciones (ej. una edición directa anterior), la diferencia se atribuye a la
+// ubicación por defecto, que es donde caen los movimientos sin ubicación.
+func findStockDiscrepancies(db *gorm.DB, productID uint) ([]StockDiscrepancy, error) {
+	defaultID, err := defaultLocationID(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var products []models.Product
+	productQuery := db.Select("id, name, stock")
+	if productID != 0 {
+		productQuery = productQuery.Where("id = ?", productID)
+	}
+	if err := productQuery.Find(&products).Error; err != nil {
+		return nil, err
+	}
+
+	var stocks []models.ProductStock
+	stockQuery := db.Model(&models.ProductStock{})
+	if productID != 0 {
+		stockQuery = stockQuery.Where("product_id = ?", productID)
+	}
+	if err := stockQuery.Find(&stocks).Error; err != nil {
+		return nil, err
+	}
+
+	var ledgerRows []struct {
+		ProductID  uint
+		LocationID *uint
+		Quantity   int
+	}
+	ledgerQuery := db.Table("movements as m").
+		Select("m.product_id, m.location_id, SUM(" + models.StockDeltaSQL("m") + ") as quantity").
+		Group("m.product_id, m.location_id")
+	if productID != 0 {
+		ledgerQuery = ledgerQuery.Where("m.product_id = ?", productID)
+	}
+	if err := ledgerQuery.Scan(&ledgerRows).Error; err != nil {
+		return nil, err
+	}
+
+	stored := map[stockKey]int{}
+	allocated := map[uint]int{}
+	for _, s := range stocks {
+		stored[stockKey{s.ProductID, s.LocationID}] = s.Quantity
+		allocated[s.ProductID] += s.Quantity
+	}
+
+	ledger := map[stockKey]int{}
+	for _, row := range ledgerRows {
+		locationID := defaultID
+		if row.LocationID != nil {
+			locationID = *row.LocationID
+		}
+		ledger[stockKey{row.ProductID, locationID}] += row.Quantity
+	}
+
+	names := map[uint]string{}
+	for _, p := range products {
+		names[p.ID] = p.Name
+		if unallocated := p.Stock - allocated[p.ID]; unallocated != 0 {
+			stored[stockKey{p.ID, defaultID}] += unallocated
+		}
+	}
+
+	var locations []models.Location
+	if err := db.Find(&locations).Error; err != nil {
+		return nil, err
+	}
+	locationNames := map[uint]string{}
+	for _, l := range locations {
+		locationNames[l.ID] = l.Name
+	}
+
+	// Los productos eliminados no se reconcilian
+	keys := map[stockKey]bool{}
+	for key := range stored {
+		keys[key] = true
+	}
+	for key := range ledger {
+		keys[key] = true
+	}
+
+	discrepancies := []StockDiscrepancy{}
+	for key := range keys {
+		name, ok := names[key.ProductID]
+		if !ok || stored[key] == ledger[key] {
+			continue
+		}
+		discrepancies = append(discrepancies, StockDiscrepancy{
+			ProductID:    key.ProductID,
+			ProductName:  name,
+			LocationID:   key.LocationID,
+			LocationName: locationNames[key.LocationID],
+			StoredStock:  stored[key],
+			LedgerStock:  ledger[key],
+			Difference:   stored[key] - ledger[key],
+		})
+	}
+
+	sort.Slice(discrepancies, func(i, j int) bool {
+		if discrepancies[i].ProductID != discrepancies[j].ProductID {
+			return discrepancies[i].ProductID < discrepancies[j].ProductID
+		}
+		return discrepancies[i].LocationID < discrepancies[j].LocationID
+	})
+
+	return discrepancies, nil
+}
+
+// ReconcileStock registra un movimiento de ajuste por cada discrepancia para que el
+// historial de movimientos vuelva a explicar el stock actual del producto en cada ubicación.
+// El stock guardado se conserva: el ajuste documenta la diferencia existente.
+func ReconcileStock(db *gorm.DB, userID uint) ([]StockDiscrepancy, error) {
+	pending, err := FindStockDiscrepancies(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var productIDs []uint
+	for i, d := range pending {
+		if i == 0 || pending[i-1].ProductID != d.ProductID {
+			productIDs = append(productIDs, d.ProductID)
+		}
+	}
+
+	discrepancies := []StockDiscrepancy{}
+	for _, productID := range productIDs {
+		var fixed []StockDiscrepancy
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			// Bloquear el producto y recalcular dentro de la transacción
+			var product models.Product
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, productID).Error; err != nil {
+				return err
+			}
+
+			current, err := findStockDiscrepancies(tx, product.ID)
+			if err != nil {
+				return err
+			}
+			fixed = current
+			if len(current) == 0 {
+				return nil
+			}
+
+			// Primero el stock vuelve a lo que explican los movimientos y luego cada ajuste,
+			// registrado con applyMovement, lo lleva al valor guardado con su ubicación y su costo
+			total := product.Stock
+			for _, d := range current {
+				stock := models.ProductStock{ProductID: product.ID, LocationID: d.LocationID}
+				if err := tx.Where(stock).FirstOrCreate(&stock).Error; err != nil {
+					return err
+				}
+				if err := tx.Model(&stock).Update("quantity", d.LedgerStock).Error; err != nil {
+					return err
+				}
+				total -= d.Difference
+			}
+			if err := tx.Model(&product).Update("stock", total).Error; err != nil {
+				return err
+			}
+
+			reasonCodeID := systemReasonCodeID(tx, models.ReasonCodeReconciliacion)
+			for i := range fixed {
+				d := &fixed[i]
+				locationID := d.LocationID
+
+				adjustment := models.Movement{
+					ProductID:    product.ID,
+					UserID:       userID,
+					LocationID:   &locationID,
+					Type:         models.MovementTypeAjusteEntrada,
+					Quantity:     d.Difference,
+					ReasonCodeID: reasonCodeID,
+					MovementDate: time.Now(),
+					Description: fmt.Sprintf("Ajuste de reconciliación en %s: stock registrado %d, según movimientos %d",
+						d.LocationName, d.StoredStock, d.LedgerStock),
+				}
+				if d.Difference < 0 {
+					adjustment.Type = models.MovementTypeAjusteSalida
+					adjustment.Quantity = -d.Difference
+				}
+
+				if _, err := applyMovement(tx, &adjustment); err != nil {
+					return err
+				}
+				d.AdjustmentMovementID = &adjustment.ID
+			}
+			return nil
+		})
+		if err != nil {
+			return discrepancies, err
+		}
+		discrepancies = append(discrepancies, fixed...)
+	}
+
+	return discrepancies, nil
+}
+
+// GET /api/stock/reconciliation - Reporte de diferencias entre stock y movimientos (solo admin)
+func GetStockReconciliation(c *gin.Context) {
+	discrepancies, err := FindStockDiscrepancies(config.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al calcular la reconciliación"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"discrepancies": discrepancies,
+		"total":         len(discrepancies),
+	})
+}
+
+// POST /api/stock/reconciliation - Corregir diferencias con movimientos de ajuste (solo admin)
+func FixStockReconciliation(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	discrepancies, err := ReconcileStock(config.DB, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al registrar los ajustes de reconciliación"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Reconciliación completada",
+		"discrepancies": discrepancies,
+		"total":         len(discrepancies),
+	})
+}
@@ -0,0 +1,188 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Stormdead/inventory-control-panel/backend/config"
+	"github.com/Stormdead/inventory-control-panel/backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Punto de pedido usado cuando ni el producto ni su categoría definen uno
+const defaultReorderPoint = 10
+
+// globalReorderPoint lee el punto de pedido global de REORDER_POINT
+func globalReorderPoint() int {
+	if value, err := strconv.Atoi(os.Getenv("REORDER_POINT")); err == nil && value >= 0 {
+		return value
+	}
+	return defaultReorderPoint
+}
+
+// stockLevelSQL devuelve la expresión SQL del nivel efectivo (min_stock, max_stock o
+// reorder_point) de los productos de table: el del producto, si no el default de su
+// categoría y si no fallback
+func stockLevelSQL(table, level, fallback string) string {
+	return fmt.Sprintf("COALESCE(%[1]s.%[2]s, (SELECT categories.default_%[2]s FROM categories WHERE categories.id = %[1]s.category_id), %[3]s)",
+		table, level, fallback)
+}
+
+// reorderPointSQL devuelve la expresión SQL del punto de pedido efectivo de los productos de table
+func reorderPointSQL(table string) string {
+	return stockLevelSQL(table, "reorder_point", strconv.Itoa(globalReorderPoint()))
+}
+
+// validateStockLevels verifica que los niveles no sean negativos y que mínimo <= punto de pedido <= máximo
+func validateStockLevels(min, reorderPoint, max *int) error {
+	for _, level := range []*int{min, reorderPoint, max} {
+		if level != nil && *level < 0 {
+			return errors.New("Los niveles de stock no pueden ser negativos")
+		}
+	}
+	if min != nil && reorderPoint != nil && *min > *reorderPoint {
+		return errors.New("El stock mínimo no puede superar el punto de pedido")
+	}
+	if reorderPoint != nil && max != nil && *reorderPoint > *max {
+		return errors.New("El punto de pedido no puede superar el stock máximo")
+	}
+	if min != nil && max != nil && *min > *max {
+		return errors.New("El stock mínimo no puede superar el stock máximo")
+	}
+	return nil
+}
+
+// GET /api/products/replenishment - Sugerencias de reposición
+// (?days=30 consumo a considerar, ?coverage_days=30 días a cubrir sin máximo, ?category_id=, ?all=true)
+//
+// La posición de cada producto es stock - reservado + en pedido. Si queda por debajo del
+// punto de pedido se sugiere reponer hasta el stock máximo o, si no tiene, hasta el punto
+// de pedido más el consumo promedio de salidas durante coverage_days.
+func GetReplenishmentSuggestions(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days debe ser un número positivo"})
+		return
+	}
+	coverageDays, err := strconv.Atoi(c.DefaultQuery("coverage_days", "30"))
+	if err != nil || coverageDays <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "coverage_days debe ser un número positivo"})
+		return
+	}
+
+	type Suggestion struct {
+		ProductID         uint    `json:"product_id"`
+		SKU               *string `json:"sku"`
+		ProductName       string  `json:"product_name"`
+		CategoryName      *string `json:"category_name"`
+		Stock             int     `json:"stock"`
+		Reserved          int     `json:"reserved" gorm:"-"`
+		OnOrder           int     `json:"on_order" gorm:"-"`
+		Position          int     `json:"position" gorm:"-"`
+		MinStock          int     `json:"min_stock"`
+		ReorderPoint      int     `json:"reorder_point"`
+		MaxStock          *int    `json:"max_stock"`
+		Consumption       int     `json:"consumption" gorm:"-"`
+		DailyUsage        float64 `json:"daily_usage" gorm:"-"`
+		BelowMin          bool    `json:"below_min" gorm:"-"`
+		SuggestedQuantity int     `json:"suggested_quantity" gorm:"-"`
+	}
+
+	query := config.DB.Table("products as p").
+		Select("p.id as product_id, p.sku, p.name as product_name, c.name as category_name, p.stock, " +
+			stockLevelSQL("p", "min_stock", "0") + " as min_stock, " +
+			reorderPointSQL("p") + " as reorder_point, " +
+			stockLevelSQL("p", "max_stock", "NULL") + " as max_stock").
+		Joins("LEFT JOIN categories c ON c.id = p.category_id").
+		Where("p.deleted_at IS NULL").
+		Order("p.id")
+	if categoryID := c.Query("category_id"); categoryID != "" {
+		query = query.Where("p.category_id = ?", categoryID)
+	}
+
+	var products []Suggestion
+	if err := query.Scan(&products).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al calcular la reposición"})
+		return
+	}
+
+	ids := make([]uint, len(products))
+	for i, p := range products {
+		ids[i] = p.ProductID
+	}
+
+	// Consumo reciente: salidas comerciales no revertidas
+	var consumption []struct {
+		ProductID uint
+		Quantity  int
+	}
+	if len(ids) > 0 {
+		if err := summaryMovements("", time.Now().AddDate(0, 0, -days), time.Now()).
+			Select("m.product_id, SUM(m.quantity) as quantity").
+			Where(models.ConsumptionSQL("m")).
+			Where("m.product_id IN ?", ids).
+			Group("m.product_id").
+			Scan(&consumption).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al calcular la reposición"})
+			return
+		}
+	}
+	consumed := map[uint]int{}
+	for _, row := range consumption {
+		consumed[row.ProductID] = row.Quantity
+	}
+
+	onOrder := onOrderQuantities(config.DB, ids)
+	reserved := reservedQuantities(config.DB, ids)
+
+	all := c.Query("all") == "true"
+	suggestions := []Suggestion{}
+	totalQuantity := 0
+	for _, s := range products {
+		s.Reserved = reserved[s.ProductID]
+		s.OnOrder = onOrder[s.ProductID]
+		s.Position = s.Stock - s.Reserved + s.OnOrder
+		s.Consumption = consumed[s.ProductID]
+		s.DailyUsage = math.Round(float64(s.Consumption)/float64(days)*100) / 100
+		s.BelowMin = s.Stock-s.Reserved < s.MinStock
+
+		if s.Position < s.ReorderPoint {
+			target := float64(s.ReorderPoint) + float64(s.Consumption)/float64(days)*float64(coverageDays)
+			if s.MaxStock != nil {
+				target = float64(*s.MaxStock)
+			}
+			target = math.Max(target, float64(s.MinStock))
+			s.SuggestedQuantity = int(math.Ceil(target)) - s.Position
+			if s.SuggestedQuantity < 0 {
+				s.SuggestedQuantity = 0
+			}
+		}
+
+		if all || s.SuggestedQuantity > 0 {
+			suggestions = append(suggestions, s)
+			totalQuantity += s.SuggestedQuantity
+		}
+	}
+
+	// Primero lo que está por debajo del mínimo, luego lo más alejado de su punto de pedido
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].BelowMin != suggestions[j].BelowMin {
+			return suggestions[i].BelowMin
+		}
+		return suggestions[i].Position-suggestions[i].ReorderPoint < suggestions[j].Position-suggestions[j].ReorderPoint
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"products":       suggestions,
+		"total":          len(suggestions),
+		"total_quantity": totalQuantity,
+		"days":           days,
+		"coverage_days":  coverageDays,
+	})
+}
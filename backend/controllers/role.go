@@ -0,0 +1,262 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Stormdead/inventory-control-panel/backend/config"
+	"github.com/Stormdead/inventory-control-panel/backend/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Los nombres de rol se guardan en users.role: minúsculas, dígitos y guion bajo
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,49}$`)
+
+type CreateRoleRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	Permissions string `json:"permissions"` // Separados por coma
+}
+
+// UpdateRoleRequest solo modifica los campos presentes; el nombre no se puede cambiar
+type UpdateRoleRequest struct {
+	Description *string `json:"description"`
+	Permissions *string `json:"permissions"`
+}
+
+// roleExists indica si hay un rol con ese nombre
+func roleExists(db *gorm.DB, name string) bool {
+	var count int64
+	db.Model(&models.Role{}).Where("name = ?", name).Count(&count)
+	return count > 0
+}
+
+// callerRole devuelve el rol del usuario autenticado, cargado por AuthMiddleware
+func callerRole(c *gin.Context) *models.Role {
+	if value, exists := c.Get("permissions"); exists {
+		return value.(*models.Role)
+	}
+	return &models.Role{}
+}
+
+// assignableRole verifica que el rol exista y que el usuario autenticado tenga todos
+// sus permisos: nadie puede otorgar, asignando un rol, más de lo que él mismo tiene
+func assignableRole(c *gin.Context, name string) (int, error) {
+	var role models.Role
+	if err := config.DB.Where("name = ?", name).First(&role).Error; err != nil {
+		return http.StatusBadRequest, errors.New("Rol inválido")
+	}
+	if !callerRole(c).Covers(&role) {
+		return http.StatusForbidden, errors.New("No puede asignar un rol con permisos que usted no tiene")
+	}
+	return 0, nil
+}
+
+// normalizePermissions valida la lista de permisos separados por coma y la devuelve
+// ordenada y sin repetidos
+func normalizePermissions(value string) (string, error) {
+	seen := map[string]bool{}
+	var permissions []string
+	for _, p := range strings.Split(value, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" || seen[p] {
+			continue
+		}
+		if !models.ValidPermission(p) {
+			return "", errors.New("Permiso inválido: " + p)
+		}
+		seen[p] = true
+		permissions = append(permissions, p)
+	}
+	sort.Strings(permissions)
+	return strings.Join(permissions, ","), nil
+}
+
+// GET /api/roles - Listar roles
+func GetRoles(c *gin.Context) {
+	var roles []models.Role
+
+	if err := config.DB.Order("name").Find(&roles).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener roles"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"roles": roles,
+		"total": len(roles),
+	})
+}
+
+// GET /api/roles/permissions - Catálogo de permisos asignables
+func GetPermissions(c *gin.Context) {
+	names := make([]string, 0, len(models.PermissionCatalog))
+	for name := range models.PermissionCatalog {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	permissions := make([]gin.H, 0, len(names))
+	for _, name := range names {
+		permissions = append(permissions, gin.H{"name": name, "description": models.PermissionCatalog[name]})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"permissions": permissions,
+	})
+}
+
+// GET /api/roles/:id - Obtener un rol con la cantidad de usuarios que lo tienen
+func GetRole(c *gin.Context) {
+	var role models.Role
+	if err := config.DB.First(&role, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rol no encontrado"})
+		return
+	}
+
+	var users int64
+	config.DB.Model(&models.User{}).Where("role = ?", role.Name).Count(&users)
+
+	c.JSON(http.StatusOK, gin.H{
+		"role":       role,
+		"user_count": users,
+	})
+}
+
+// POST /api/roles - Crear un rol con sus permisos
+func CreateRole(c *gin.Context) {
+	var req CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if !roleNamePattern.MatchString(name) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nombre de rol inválido; use minúsculas, dígitos y guion bajo"})
+		return
+	}
+	permissions, err := normalizePermissions(req.Permissions)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !callerRole(c).Covers(&models.Role{Permissions: permissions}) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "No puede otorgar permisos que usted no tiene"})
+		return
+	}
+
+	if roleExists(config.DB, name) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Ya existe un rol con ese nombre"})
+		return
+	}
+
+	role := models.Role{
+		Name:        name,
+		Description: req.Description,
+		Permissions: permissions,
+	}
+	if err := config.DB.Create(&role).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear el rol"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Rol creado exitosamente",
+		"role":    role,
+	})
+}
+
+// PUT /api/roles/:id - Actualizar descripción o permisos de un rol.
+// Los cambios aplican en la siguiente petición de cada usuario con ese rol.
+func UpdateRole(c *gin.Context) {
+	var req UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var role models.Role
+	if err := config.DB.First(&role, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rol no encontrado"})
+		return
+	}
+
+	// admin conserva siempre todos los permisos para que nadie quede sin acceso a la gestión
+	if role.Name == models.RoleAdmin {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El rol admin no se puede modificar"})
+		return
+	}
+	if !callerRole(c).Covers(&role) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "No puede modificar un rol con permisos que usted no tiene"})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if req.Permissions != nil {
+		permissions, err := normalizePermissions(*req.Permissions)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if !callerRole(c).Covers(&models.Role{Permissions: permissions}) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "No puede otorgar permisos que usted no tiene"})
+			return
+		}
+		updates["permissions"] = permissions
+	}
+
+	if len(updates) > 0 {
+		if err := config.DB.Model(&role).Updates(updates).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar el rol"})
+			return
+		}
+	}
+
+	config.DB.First(&role, role.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Rol actualizado exitosamente",
+		"role":    role,
+	})
+}
+
+// DELETE /api/roles/:id - Eliminar un rol sin usuarios ni invitaciones pendientes
+func DeleteRole(c *gin.Context) {
+	var role models.Role
+	if err := config.DB.First(&role, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rol no encontrado"})
+		return
+	}
+
+	if role.System {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Los roles del sistema no se pueden eliminar"})
+		return
+	}
+
+	// Los usuarios eliminados también cuentan: conservan el rol en el historial
+	var users int64
+	config.DB.Unscoped().Model(&models.User{}).Where("role = ?", role.Name).Count(&users)
+	var invitations int64
+	config.DB.Model(&models.Invitation{}).Where("role = ? AND accepted_at IS NULL AND revoked_at IS NULL", role.Name).Count(&invitations)
+	if users > 0 || invitations > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":       "El rol está asignado; reasigne sus usuarios y revoque sus invitaciones antes de eliminarlo",
+			"users":       users,
+			"invitations": invitations,
+		})
+		return
+	}
+
+	if err := config.DB.Delete(&role).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar el rol"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Rol eliminado exitosamente"})
+}
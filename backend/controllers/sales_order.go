@@ -0,0 +1,518 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Stormdead/inventory-control-panel/backend/config"
+	"github.com/Stormdead/inventory-control-panel/backend/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SalesOrderLineRequest struct {
+	ProductID uint    `json:"product_id" binding:"required"`
+	Quantity  int     `json:"quantity" binding:"required,gt=0"`
+	UnitPrice float64 `json:"unit_price" binding:"gte=0"`
+}
+
+type SalesOrderRequest struct {
+	Customer   string                  `json:"customer" binding:"required"`
+	LocationID *uint                   `json:"location_id"` // Por defecto la ubicación principal
+	Reference  string                  `json:"reference"`
+	ShipDate   string                  `json:"ship_date"`
+	Notes      string                  `json:"notes"`
+	Lines      []SalesOrderLineRequest `json:"lines" binding:"required,min=1,dive"`
+}
+
+// FulfillSalesOrderRequest indica qué despachar; sin líneas se despacha todo lo pendiente
+type FulfillSalesOrderRequest struct {
+	Lines []struct {
+		LineID   uint `json:"line_id" binding:"required"`
+		Quantity int  `json:"quantity" binding:"required,gt=0"`
+	} `json:"lines" binding:"dive"`
+	Description string `json:"description"`
+}
+
+// reservedQuery suma lo pendiente de despachar en pedidos confirmados
+func reservedQuery(db *gorm.DB) *gorm.DB {
+	return db.Table("sales_order_lines as l").
+		Joins("JOIN sales_orders so ON so.id = l.sales_order_id").
+		Where("so.status IN ? AND l.quantity > l.fulfilled_quantity", models.ReservingSalesOrderStatuses)
+}
+
+// reservedQuantities devuelve, por producto, lo reservado por pedidos de clientes en todas las ubicaciones
+func reservedQuantities(db *gorm.DB, productIDs []uint) map[uint]int {
+	result := map[uint]int{}
+	if len(productIDs) == 0 {
+		return result
+	}
+
+	var rows []struct {
+		ProductID uint
+		Reserved  int
+	}
+	reservedQuery(db).
+		Select("l.product_id, SUM(l.quantity - l.fulfilled_quantity) as reserved").
+		Where("l.product_id IN ?", productIDs).
+		Group("l.product_id").
+		Scan(&rows)
+
+	for _, row := range rows {
+		result[row.ProductID] = row.Reserved
+	}
+	return result
+}
+
+// reservedQuantity devuelve lo reservado de un producto en una ubicación, sin contar la
+// línea de pedido excludeLineID (la que se está despachando)
+func reservedQuantity(tx *gorm.DB, productID, locationID uint, excludeLineID *uint) int {
+	query := reservedQuery(tx).
+		Select("COALESCE(SUM(l.quantity - l.fulfilled_quantity), 0)").
+		Where("l.product_id = ? AND so.location_id = ?", productID, locationID)
+	if excludeLineID != nil {
+		query = query.Where("l.id <> ?", *excludeLineID)
+	}
+
+	var reserved int
+	query.Scan(&reserved)
+	return reserved
+}
+
+// loadSalesOrder carga un pedido con sus relaciones para la respuesta
+func loadSalesOrder(order *models.SalesOrder) {
+	config.DB.Preload("Location").
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Lines.Product").
+		First(order, order.ID)
+}
+
+// salesOrderStatus calcula el estado de un pedido confirmado según lo despachado en sus líneas
+func salesOrderStatus(lines []models.SalesOrderLine) string {
+	pending, fulfilled := 0, 0
+	for _, line := range lines {
+		pending += line.Pending()
+		fulfilled += line.FulfilledQuantity
+	}
+	switch {
+	case pending == 0:
+		return models.SalesOrderStatusCompletada
+	case fulfilled > 0:
+		return models.SalesOrderStatusParcial
+	}
+	return models.SalesOrderStatusConfirmada
+}
+
+// unfulfillSalesOrderLine descuenta de una línea lo despachado por un movimiento revertido;
+// lo pendiente vuelve a quedar reservado si el pedido sigue vigente
+func unfulfillSalesOrderLine(tx *gorm.DB, lineID uint, quantity int) error {
+	var line models.SalesOrderLine
+	if err := tx.First(&line, lineID).Error; err != nil {
+		return err
+	}
+
+	var order models.SalesOrder
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Lines").First(&order, line.SalesOrderID).Error; err != nil {
+		return err
+	}
+
+	fulfilled := line.FulfilledQuantity - quantity
+	if fulfilled < 0 {
+		fulfilled = 0
+	}
+	if err := tx.Model(&line).Update("fulfilled_quantity", fulfilled).Error; err != nil {
+		return err
+	}
+
+	if order.Status == models.SalesOrderStatusCancelada {
+		return nil
+	}
+	for i := range order.Lines {
+		if order.Lines[i].ID == line.ID {
+			order.Lines[i].FulfilledQuantity = fulfilled
+		}
+	}
+	return tx.Model(&order).Update("status", salesOrderStatus(order.Lines)).Error
+}
+
+// GET /api/sales-orders - Listar pedidos de clientes (?status=, ?customer=, ?open=true)
+func GetSalesOrders(c *gin.Context) {
+	var orders []models.SalesOrder
+
+	query := config.DB.Preload("Location").Preload("Lines")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if c.Query("open") == "true" {
+		query = query.Where("status IN ?", models.ReservingSalesOrderStatuses)
+	}
+	if customer := strings.TrimSpace(c.Query("customer")); customer != "" {
+		query = query.Where("customer LIKE ?", "%"+customer+"%")
+	}
+
+	if err := query.Order("created_at DESC").Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener pedidos"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sales_orders": orders,
+		"total":        len(orders),
+	})
+}
+
+// GET /api/sales-orders/:id - Obtener un pedido con sus líneas y despachos
+func GetSalesOrder(c *gin.Context) {
+	id := c.Param("id")
+	var order models.SalesOrder
+
+	if err := config.DB.First(&order, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pedido no encontrado"})
+		return
+	}
+
+	loadSalesOrder(&order)
+
+	var shipments []models.Movement
+	config.DB.Preload("User", unscoped).
+		Where("sales_order_line_id IN (?)",
+			config.DB.Model(&models.SalesOrderLine{}).Select("id").Where("sales_order_id = ?", order.ID)).
+		Order("id").
+		Find(&shipments)
+
+	c.JSON(http.StatusOK, gin.H{
+		"sales_order": order,
+		"shipments":   shipments,
+	})
+}
+
+// POST /api/sales-orders - Crear un pedido en borrador; no reserva stock hasta confirmarlo
+func CreateSalesOrder(c *gin.Context) {
+	var req SalesOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	customer := strings.TrimSpace(req.Customer)
+	if customer == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El cliente es requerido"})
+		return
+	}
+
+	locationID, err := resolveLocationID(config.DB, req.LocationID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La ubicación especificada no existe"})
+		return
+	}
+
+	shipDate, err := parseOptionalDate(req.ShipDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	order := models.SalesOrder{
+		Customer:    customer,
+		Reference:   req.Reference,
+		LocationID:  locationID,
+		Status:      models.SalesOrderStatusBorrador,
+		ShipDate:    shipDate,
+		Notes:       req.Notes,
+		CreatedByID: userID.(uint),
+	}
+
+	for i, line := range req.Lines {
+		var count int64
+		config.DB.Model(&models.Product{}).Where("id = ?", line.ProductID).Count(&count)
+		if count == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Línea %d: el producto no existe", i+1)})
+			return
+		}
+
+		order.Lines = append(order.Lines, models.SalesOrderLine{
+			ProductID: line.ProductID,
+			Quantity:  line.Quantity,
+			UnitPrice: line.UnitPrice,
+		})
+	}
+
+	// El pedido y sus líneas se crean juntos
+	if err := config.DB.Create(&order).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear el pedido"})
+		return
+	}
+
+	loadSalesOrder(&order)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":     "Pedido creado exitosamente",
+		"sales_order": order,
+	})
+}
+
+// POST /api/sales-orders/:id/confirm - Confirmar un pedido en borrador reservando su stock.
+// Solo se confirma si el disponible (stock menos reservas) de la ubicación alcanza para todas las líneas.
+func ConfirmSalesOrder(c *gin.Context) {
+	tx := config.DB.Begin()
+
+	var order models.SalesOrder
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Lines").First(&order, c.Param("id")).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Pedido no encontrado"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al confirmar el pedido"})
+		return
+	}
+
+	if order.Status != models.SalesOrderStatusBorrador {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": "Solo se pueden confirmar pedidos en borrador", "status": order.Status})
+		return
+	}
+
+	// Cantidad pedida por producto (un producto puede repetirse en varias líneas)
+	needed := map[uint]int{}
+	var productIDs []uint
+	for _, line := range order.Lines {
+		if _, ok := needed[line.ProductID]; !ok {
+			productIDs = append(productIDs, line.ProductID)
+		}
+		needed[line.ProductID] += line.Quantity
+	}
+
+	// Bloquear los productos en orden de ID, como applyMovement, para que la reserva y
+	// las salidas concurrentes no usen las mismas unidades
+	sort.Slice(productIDs, func(i, j int) bool { return productIDs[i] < productIDs[j] })
+	var products []models.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", productIDs).Order("id").Find(&products).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al confirmar el pedido"})
+		return
+	}
+
+	type Shortage struct {
+		ProductID uint `json:"product_id"`
+		Requested int  `json:"solicitado"`
+		Stock     int  `json:"stock_actual"`
+		Reserved  int  `json:"reservado"`
+		Available int  `json:"disponible"`
+	}
+	var shortages []Shortage
+	for _, productID := range productIDs {
+		var stock models.ProductStock
+		tx.Where("product_id = ? AND location_id = ?", productID, order.LocationID).Find(&stock)
+		reserved := reservedQuantity(tx, productID, order.LocationID, nil)
+		if available := stock.Quantity - reserved; available < needed[productID] {
+			shortages = append(shortages, Shortage{ProductID: productID, Requested: needed[productID],
+				Stock: stock.Quantity, Reserved: reserved, Available: available})
+		}
+	}
+	if len(shortages) > 0 {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{
+			"error":     "Stock disponible insuficiente para confirmar el pedido",
+			"faltantes": shortages,
+		})
+		return
+	}
+
+	now := time.Now()
+	if err := tx.Model(&order).Updates(map[string]interface{}{
+		"status":       models.SalesOrderStatusConfirmada,
+		"confirmed_at": now,
+	}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al confirmar el pedido"})
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al confirmar el pedido"})
+		return
+	}
+
+	loadSalesOrder(&order)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Pedido confirmado; stock reservado",
+		"sales_order": order,
+	})
+}
+
+// POST /api/sales-orders/:id/fulfill - Despachar un pedido confirmado, total o parcialmente.
+// Cada línea despachada genera una salida desde la ubicación del pedido y libera su reserva.
+func FulfillSalesOrder(c *gin.Context) {
+	var req FulfillSalesOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	tx := config.DB.Begin()
+
+	// Bloquear el pedido para que dos despachos simultáneos no superen lo pedido
+	var order models.SalesOrder
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Lines").First(&order, c.Param("id")).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pedido no encontrado"})
+		return
+	}
+
+	if order.Status != models.SalesOrderStatusConfirmada && order.Status != models.SalesOrderStatusParcial {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": "El pedido no está confirmado", "status": order.Status})
+		return
+	}
+
+	// Sin líneas en el request se despacha todo lo pendiente
+	type fulfilment struct {
+		line     *models.SalesOrderLine
+		quantity int
+	}
+	var items []fulfilment
+	if len(req.Lines) == 0 {
+		for i := range order.Lines {
+			if pending := order.Lines[i].Pending(); pending > 0 {
+				items = append(items, fulfilment{&order.Lines[i], pending})
+			}
+		}
+	}
+	for _, item := range req.Lines {
+		var line *models.SalesOrderLine
+		for i := range order.Lines {
+			if order.Lines[i].ID == item.LineID {
+				line = &order.Lines[i]
+			}
+		}
+		if line == nil {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("La línea %d no pertenece al pedido", item.LineID)})
+			return
+		}
+		items = append(items, fulfilment{line, item.Quantity})
+	}
+
+	description := req.Description
+	if description == "" {
+		description = fmt.Sprintf("Despacho del pedido #%d (%s)", order.ID, order.Customer)
+	}
+
+	var shipments []models.Movement
+	for _, item := range items {
+		line := item.line
+		if item.quantity > line.Pending() {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":     "La cantidad despachada supera lo pendiente de la línea",
+				"line_id":   line.ID,
+				"pendiente": line.Pending(),
+			})
+			return
+		}
+
+		shipment := models.Movement{
+			ProductID:        line.ProductID,
+			UserID:           userID.(uint),
+			LocationID:       &order.LocationID,
+			Type:             models.MovementTypeSalida,
+			Quantity:         item.quantity,
+			Description:      description,
+			SalesOrderLineID: &line.ID,
+			MovementDate:     time.Now(),
+		}
+		if _, err := applyMovement(tx, &shipment); err != nil {
+			tx.Rollback()
+			respondMovementError(c, err)
+			return
+		}
+
+		line.FulfilledQuantity += item.quantity
+		if err := tx.Model(line).Update("fulfilled_quantity", line.FulfilledQuantity).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al registrar el despacho"})
+			return
+		}
+		shipments = append(shipments, shipment)
+	}
+
+	order.Status = salesOrderStatus(order.Lines)
+	if err := tx.Model(&order).Update("status", order.Status).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al registrar el despacho"})
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al registrar el despacho"})
+		return
+	}
+
+	loadSalesOrder(&order)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Despacho registrado exitosamente",
+		"sales_order": order,
+		"shipments":   shipments,
+	})
+}
+
+// POST /api/sales-orders/:id/cancel - Cancelar un pedido y liberar lo que tenía reservado (solo admin).
+// Lo ya despachado queda registrado; para devolverlo se revierten sus movimientos.
+func CancelSalesOrder(c *gin.Context) {
+	tx := config.DB.Begin()
+
+	var order models.SalesOrder
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, c.Param("id")).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Pedido no encontrado"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al cancelar el pedido"})
+		return
+	}
+
+	if order.Status == models.SalesOrderStatusCompletada || order.Status == models.SalesOrderStatusCancelada {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": "El pedido ya está cerrado", "status": order.Status})
+		return
+	}
+
+	if err := tx.Model(&order).Update("status", models.SalesOrderStatusCancelada).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al cancelar el pedido"})
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al cancelar el pedido"})
+		return
+	}
+
+	loadSalesOrder(&order)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Pedido cancelado exitosamente",
+		"sales_order": order,
+	})
+}
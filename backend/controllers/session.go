@@ -0,0 +1,185 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Stormdead/inventory-control-panel/backend/config"
+	"github.com/Stormdead/inventory-control-panel/backend/models"
+	"github.com/Stormdead/inventory-control-panel/backend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// sessionTokens es el par de tokens que se entrega al iniciar sesión o refrescar
+type sessionTokens struct {
+	AccessToken  string
+	RefreshToken string
+	record       models.RefreshToken
+}
+
+// issueTokens crea un refresh token en la familia indicada (una nueva si familyID está vacío)
+// y firma un token de acceso ligado a ella. Solo el hash del refresh token queda en la base.
+func issueTokens(tx *gorm.DB, user models.User, familyID string) (*sessionTokens, error) {
+	if familyID == "" {
+		id, err := utils.GenerateOpaqueToken()
+		if err != nil {
+			return nil, err
+		}
+		familyID = id
+	}
+
+	refresh, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	record := models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: utils.HashToken(refresh),
+		ExpiresAt: time.Now().Add(utils.RefreshTokenTTL()),
+	}
+	if err := tx.Create(&record).Error; err != nil {
+		return nil, err
+	}
+
+	access, err := utils.GenerateToken(user.ID, user.Username, user.Role, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sessionTokens{AccessToken: access, RefreshToken: refresh, record: record}, nil
+}
+
+// response arma los campos de tokens de la respuesta; "token" se mantiene por compatibilidad
+func (t *sessionTokens) response() gin.H {
+	return gin.H{
+		"token":         t.AccessToken,
+		"refresh_token": t.RefreshToken,
+		"expires_in":    int(utils.AccessTokenTTL().Seconds()),
+	}
+}
+
+// authResponse une el mensaje, los tokens y los datos básicos del usuario
+func authResponse(message string, tokens *sessionTokens, user models.User) gin.H {
+	response := tokens.response()
+	response["message"] = message
+	response["user"] = gin.H{
+		"id":       user.ID,
+		"username": user.Username,
+		"email":    user.Email,
+		"role":     user.Role,
+	}
+	return response
+}
+
+// revokeFamily revoca todos los refresh tokens vigentes de una sesión
+func revokeFamily(tx *gorm.DB, familyID string) error {
+	return tx.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// revokeUserSessions revoca todas las sesiones de un usuario
+func revokeUserSessions(tx *gorm.DB, userID uint) error {
+	return tx.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// POST /api/auth/refresh - Canjear un refresh token por un nuevo par de tokens (rotación)
+func RefreshSession(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx := config.DB.Begin()
+
+	var current models.RefreshToken
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ?", utils.HashToken(req.RefreshToken)).
+		First(&current).Error
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token inválido"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al refrescar la sesión"})
+		return
+	}
+
+	// Un token ya usado o revocado que vuelve a presentarse indica que pudo ser robado:
+	// se revoca toda la familia para cortar también al que tenga el token vigente
+	if current.UsedAt != nil || current.RevokedAt != nil {
+		if err := revokeFamily(tx, current.FamilyID); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al refrescar la sesión"})
+			return
+		}
+		tx.Commit()
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token reutilizado; la sesión fue revocada"})
+		return
+	}
+
+	if time.Now().After(current.ExpiresAt) {
+		tx.Rollback()
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token expirado"})
+		return
+	}
+
+	var user models.User
+	if err := tx.Where("active = ?", true).First(&user, current.UserID).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no encontrado o desactivado"})
+		return
+	}
+
+	tokens, err := issueTokens(tx, user, current.FamilyID)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al generar token"})
+		return
+	}
+
+	now := time.Now()
+	if err := tx.Model(&current).Updates(map[string]interface{}{
+		"used_at":        now,
+		"replaced_by_id": tokens.record.ID,
+	}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al refrescar la sesión"})
+		return
+	}
+
+	tx.Commit()
+	c.JSON(http.StatusOK, tokens.response())
+}
+
+// POST /api/auth/logout - Cerrar la sesión actual (?all=true cierra todas las del usuario)
+func Logout(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	sessionID, _ := c.Get("session_id")
+
+	var err error
+	if c.Query("all") == "true" {
+		err = revokeUserSessions(config.DB, userID.(uint))
+	} else {
+		err = revokeFamily(config.DB, sessionID.(string))
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al cerrar la sesión"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Sesión cerrada exitosamente"})
+}
@@ -0,0 +1,367 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Stormdead/inventory-control-panel/backend/config"
+	"github.com/Stormdead/inventory-control-panel/backend/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errSnapshotExists = errors.New("ya existe una foto de stock para esa fecha")
+
+// periodLockedError se devuelve al registrar un movimiento dentro de un período cerrado
+type periodLockedError struct {
+	Until time.Time
+}
+
+func (e *periodLockedError) Error() string {
+	return "el período está cerrado hasta el " + e.Until.Format("2006-01-02 15:04:05")
+}
+
+// stockPosition es el stock y el valor de un producto a una fecha
+type stockPosition struct {
+	ProductID    uint    `json:"product_id"`
+	SKU          *string `json:"sku"`
+	ProductName  string  `json:"product_name"`
+	CategoryName *string `json:"category_name"`
+	Quantity     int     `json:"quantity"`
+	Value        float64 `json:"value"`      // A costo promedio
+	FifoValue    float64 `json:"fifo_value"` // Según capas FIFO
+}
+
+// latestSnapshot devuelve la última foto con fecha igual o anterior a asOf (nil si no hay)
+func latestSnapshot(db *gorm.DB, asOf time.Time) *models.StockSnapshot {
+	var snapshot models.StockSnapshot
+	if err := db.Where("snapshot_date <= ?", asOf).Order("snapshot_date DESC").First(&snapshot).Error; err != nil {
+		return nil
+	}
+	return &snapshot
+}
+
+// ledgerPositions arma la consulta con las filas (product_id, location_id, quantity, value, fifo_value)
+// cuya suma da el stock a la fecha: las líneas de la última foto anterior más los movimientos posteriores.
+func ledgerPositions(db *gorm.DB, asOf time.Time, locationID string) (*gorm.DB, *models.StockSnapshot) {
+	movements := db.Table("movements as m").
+		Select("m.product_id, m.location_id, "+
+			models.StockDeltaSQL("m")+" as quantity, "+
+			models.CostDeltaSQL("m", models.CostColumn(models.ValuationAverage))+" as value, "+
+			models.CostDeltaSQL("m", models.CostColumn(models.ValuationFIFO))+" as fifo_value").
+		Where("m.movement_date <= ?", asOf)
+	if locationID != "" {
+		movements = movements.Where("m.location_id = ?", locationID)
+	}
+
+	snapshot := latestSnapshot(db, asOf)
+	if snapshot == nil {
+		return movements, nil
+	}
+
+	movements = movements.Where("m.movement_date > ?", snapshot.SnapshotDate)
+	lines := db.Table("stock_snapshot_lines as l").
+		Select("l.product_id, l.location_id, l.quantity, l.value, l.fifo_value").
+		Where("l.snapshot_id = ?", snapshot.ID)
+	if locationID != "" {
+		lines = lines.Where("l.location_id = ?", locationID)
+	}
+
+	return db.Raw("? UNION ALL ?", lines, movements), snapshot
+}
+
+// stockAsOf calcula el stock y el valor de cada producto a la fecha, opcionalmente de una
+// ubicación o categoría. Devuelve también la foto usada como punto de partida.
+func stockAsOf(db *gorm.DB, asOf time.Time, locationID, categoryID string) ([]stockPosition, *models.StockSnapshot, error) {
+	positions, snapshot := ledgerPositions(db, asOf, locationID)
+
+	query := db.Table("(?) as t", positions).
+		Select("t.product_id, p.sku, p.name as product_name, c.name as category_name, " +
+			"SUM(t.quantity) as quantity, SUM(t.value) as value, SUM(t.fifo_value) as fifo_value").
+		Joins("JOIN products p ON p.id = t.product_id").
+		Joins("LEFT JOIN categories c ON c.id = p.category_id").
+		Group("t.product_id, p.sku, p.name, c.name").
+		Having("SUM(t.quantity) <> 0 OR SUM(t.value) <> 0 OR SUM(t.fifo_value) <> 0").
+		Order("p.name")
+	if categoryID != "" {
+		query = query.Where("p.category_id = ?", categoryID)
+	}
+
+	var result []stockPosition
+	if err := query.Scan(&result).Error; err != nil {
+		return nil, nil, err
+	}
+	for i := range result {
+		result[i].Value = roundCost(result[i].Value)
+		result[i].FifoValue = roundCost(result[i].FifoValue)
+	}
+	return result, snapshot, nil
+}
+
+// parseAsOf lee ?as_of= (fecha o RFC3339; por defecto ahora). Una fecha sin hora
+// se toma hasta el final del día.
+func parseAsOf(c *gin.Context) (time.Time, error) {
+	value := c.Query("as_of")
+	if value == "" {
+		return time.Now(), nil
+	}
+	return parseDateParam(value, true)
+}
+
+// GET /api/stock/as-of - Stock y valor de cada producto a una fecha (?as_of=&location_id=&category_id=)
+func GetStockAsOf(c *gin.Context) {
+	asOf, err := parseAsOf(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	positions, snapshot, err := stockAsOf(config.DB, asOf, c.Query("location_id"), c.Query("category_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al calcular el stock a la fecha"})
+		return
+	}
+
+	totalQuantity, totalValue, totalFifo := 0, 0.0, 0.0
+	for _, p := range positions {
+		totalQuantity += p.Quantity
+		totalValue += p.Value
+		totalFifo += p.FifoValue
+	}
+
+	response := gin.H{
+		"as_of":            asOf,
+		"products":         positions,
+		"total":            len(positions),
+		"total_quantity":   totalQuantity,
+		"total_value":      roundCost(totalValue),
+		"total_fifo_value": roundCost(totalFifo),
+	}
+	if snapshot != nil {
+		response["snapshot_id"] = snapshot.ID
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// checkOpenPeriod verifica que la fecha de un movimiento no caiga en un período cerrado
+// y descarta las fotos sin bloquear que el movimiento dejaría desactualizadas.
+func checkOpenPeriod(tx *gorm.DB, date time.Time) error {
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	var locked models.StockSnapshot
+	if err := tx.Where("locked = ? AND snapshot_date >= ?", true, date).
+		Order("snapshot_date DESC").First(&locked).Error; err == nil {
+		return &periodLockedError{Until: locked.SnapshotDate}
+	}
+
+	stale := tx.Model(&models.StockSnapshot{}).Select("id").Where("locked = ? AND snapshot_date >= ?", false, date)
+	if err := tx.Where("snapshot_id IN (?)", stale).Delete(&models.StockSnapshotLine{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("locked = ? AND snapshot_date >= ?", false, date).Delete(&models.StockSnapshot{}).Error
+}
+
+// CreateStockSnapshot guarda la foto del stock a la fecha asOf, por producto y ubicación
+func CreateStockSnapshot(db *gorm.DB, asOf time.Time, snapshotType string, userID *uint) (*models.StockSnapshot, error) {
+	// La base guarda milisegundos; se trunca para que la fecha guardada no cambie al redondear
+	asOf = asOf.Truncate(time.Millisecond)
+
+	var snapshot models.StockSnapshot
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		tx.Model(&models.StockSnapshot{}).Where("snapshot_date = ?", asOf).Count(&count)
+		if count > 0 {
+			return errSnapshotExists
+		}
+
+		positions, _ := ledgerPositions(tx, asOf, "")
+		var lines []models.StockSnapshotLine
+		if err := tx.Table("(?) as t", positions).
+			Select("t.product_id, t.location_id, SUM(t.quantity) as quantity, SUM(t.value) as value, SUM(t.fifo_value) as fifo_value").
+			Group("t.product_id, t.location_id").
+			Having("SUM(t.quantity) <> 0 OR SUM(t.value) <> 0 OR SUM(t.fifo_value) <> 0").
+			Scan(&lines).Error; err != nil {
+			return err
+		}
+
+		snapshot = models.StockSnapshot{SnapshotDate: asOf, Type: snapshotType, CreatedByID: userID}
+		if err := tx.Create(&snapshot).Error; err != nil {
+			return err
+		}
+
+		if len(lines) == 0 {
+			return nil
+		}
+		for i := range lines {
+			lines[i].SnapshotID = snapshot.ID
+		}
+		return tx.CreateInBatches(lines, 500).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// MonthEnd devuelve el último instante del mes indicado ("2006-01")
+func MonthEnd(month string) (time.Time, error) {
+	start, err := time.ParseInLocation("2006-01", month, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("mes inválido: %s (use YYYY-MM)", month)
+	}
+	return start.AddDate(0, 1, 0).Add(-time.Millisecond), nil
+}
+
+// GET /api/stock/snapshots - Listar fotos de stock
+func GetStockSnapshots(c *gin.Context) {
+	var snapshots []models.StockSnapshot
+
+	query := config.DB.Order("snapshot_date DESC")
+	if snapshotType := c.Query("type"); snapshotType != "" {
+		query = query.Where("type = ?", snapshotType)
+	}
+
+	if err := query.Find(&snapshots).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener fotos de stock"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"snapshots": snapshots,
+		"total":     len(snapshots),
+	})
+}
+
+// GET /api/stock/snapshots/:id - Obtener una foto de stock con sus líneas
+func GetStockSnapshot(c *gin.Context) {
+	var snapshot models.StockSnapshot
+
+	if err := config.DB.Preload("Lines.Product").First(&snapshot, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Foto de stock no encontrada"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"snapshot": snapshot,
+	})
+}
+
+// POST /api/stock/snapshots - Tomar una foto de stock (solo admin).
+// Con "month" (YYYY-MM) se toma el cierre de ese mes; con "as_of" una fecha cualquiera.
+func TakeStockSnapshot(c *gin.Context) {
+	var req struct {
+		Month string `json:"month"`
+		AsOf  string `json:"as_of"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	asOf, snapshotType := time.Now(), models.SnapshotTypeManual
+	var err error
+	switch {
+	case req.Month != "":
+		asOf, err = MonthEnd(req.Month)
+		snapshotType = models.SnapshotTypeMensual
+	case req.AsOf != "":
+		asOf, err = parseDateParam(req.AsOf, true)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if asOf.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La fecha de la foto no puede ser futura"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	uid := userID.(uint)
+
+	snapshot, err := CreateStockSnapshot(config.DB, asOf, snapshotType, &uid)
+	if err != nil {
+		if errors.Is(err, errSnapshotExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Ya existe una foto de stock para esa fecha"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al tomar la foto de stock"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Foto de stock creada exitosamente",
+		"snapshot": snapshot,
+	})
+}
+
+// POST /api/stock/snapshots/:id/lock - Bloquear un cierre de mes (solo admin).
+// Un cierre bloqueado no se puede eliminar y cierra el período para nuevos movimientos.
+func LockStockSnapshot(c *gin.Context) {
+	var snapshot models.StockSnapshot
+	if err := config.DB.First(&snapshot, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Foto de stock no encontrada"})
+		return
+	}
+
+	if snapshot.Type != models.SnapshotTypeMensual {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Solo se pueden bloquear los cierres de mes"})
+		return
+	}
+	if snapshot.Locked {
+		c.JSON(http.StatusConflict, gin.H{"error": "El cierre ya está bloqueado"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	uid := userID.(uint)
+	now := time.Now()
+
+	if err := config.DB.Model(&snapshot).Updates(map[string]interface{}{
+		"locked":       true,
+		"locked_at":    now,
+		"locked_by_id": uid,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al bloquear el cierre"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Cierre bloqueado exitosamente",
+		"snapshot": snapshot,
+	})
+}
+
+// DELETE /api/stock/snapshots/:id - Eliminar una foto de stock sin bloquear (solo admin)
+func DeleteStockSnapshot(c *gin.Context) {
+	var snapshot models.StockSnapshot
+	if err := config.DB.First(&snapshot, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Foto de stock no encontrada"})
+		return
+	}
+
+	if snapshot.Locked {
+		c.JSON(http.StatusConflict, gin.H{"error": "No se puede eliminar un cierre bloqueado"})
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("snapshot_id = ?", snapshot.ID).Delete(&models.StockSnapshotLine{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&snapshot).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar la foto de stock"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Foto de stock eliminada exitosamente",
+	})
+}
@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Stormdead/inventory-control-panel/backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Errores de validación de movimientos
+var (
+	errProductNotFound  = errors.New("producto no encontrado")
+	errLocationNotFound = errors.New("ubicación no encontrada")
+)
+
+// insufficientStockError se devuelve cuando un movimiento dejaría el stock en negativo
+// o usaría stock reservado por pedidos de clientes
+type insufficientStockError struct {
+	Stock     int
+	Requested int
+	Reserved  int
+}
+
+func (e *insufficientStockError) Error() string {
+	return fmt.Sprintf("stock insuficiente: disponible %d, solicitado %d", e.Stock, e.Requested)
+}
+
+// isDuplicateKeyError indica si err es una violación de un índice único de MySQL
+func isDuplicateKeyError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
+
+// defaultLocationID devuelve la ubicación usada cuando un movimiento no indica ninguna
+func defaultLocationID(tx *gorm.DB) (uint, error) {
+	var location models.Location
+	if err := tx.Where(models.Location{IsDefault: true}).First(&location).Error; err != nil {
+		return 0, err
+	}
+	return location.ID, nil
+}
+
+// resolveLocationID devuelve la ubicación indicada si existe, o la ubicación por defecto si es nil
+func resolveLocationID(tx *gorm.DB, locationID *uint) (uint, error) {
+	if locationID == nil {
+		return defaultLocationID(tx)
+	}
+	var count int64
+	tx.Model(&models.Location{}).Where("id = ?", *locationID).Count(&count)
+	if count == 0 {
+		return 0, errLocationNotFound
+	}
+	return *locationID, nil
+}
+
+// systemReasonCodeID devuelve el ID de un motivo del sistema (ej. STOCK_INICIAL)
+func systemReasonCodeID(tx *gorm.DB, code string) *uint {
+	var reason models.ReasonCode
+	if err := tx.Where("code = ?", code).First(&reason).Error; err != nil {
+		return nil
+	}
+	return &reason.ID
+}
+
+// applyMovement registra el movimiento y actualiza el stock del producto dentro de tx.
+// La fila del producto se bloquea (SELECT ... FOR UPDATE) para que la validación del
+// stock y su actualización sean atómicas frente a movimientos concurrentes.
+// Si el movimiento no indica ubicación se usa la ubicación por defecto.
+func applyMovement(tx *gorm.DB, movement *models.Movement) (*models.Product, error) {
+	var product models.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, movement.ProductID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errProductNotFound
+		}
+		return nil, err
+	}
+
+	// No se aceptan movimientos con fecha dentro de un cierre bloqueado
+	if err := checkOpenPeriod(tx, movement.MovementDate); err != nil {
+		return nil, err
+	}
+
+	locationID, err := resolveLocationID(tx, movement.LocationID)
+	if err != nil {
+		return nil, err
+	}
+	movement.LocationID = &locationID
+
+	// El stock por ubicación queda protegido por el bloqueo del producto
+	stock := models.ProductStock{ProductID: product.ID, LocationID: *movement.LocationID}
+	if err := tx.Where(stock).FirstOrCreate(&stock).Error; err != nil {
+		return nil, err
+	}
+
+	delta := models.MovementSign(movement.Type) * movement.Quantity
+	if stock.Quantity+delta < 0 {
+		return nil, &insufficientStockError{Stock: stock.Quantity, Requested: movement.Quantity}
+	}
+
+	// Las salidas no pueden usar lo reservado por pedidos; el despacho de un pedido usa su propia reserva
+	if models.RespectsReservations(movement.Type) {
+		reserved := reservedQuantity(tx, product.ID, stock.LocationID, movement.SalesOrderLineID)
+		if stock.Quantity-reserved < movement.Quantity {
+			return nil, &insufficientStockError{Stock: stock.Quantity, Requested: movement.Quantity, Reserved: reserved}
+		}
+	}
+
+	// Costo del movimiento (costo de venta en las salidas) y nuevo costo promedio
+	layer, err := costMovement(tx, &product, movement)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Create(movement).Error; err != nil {
+		return nil, err
+	}
+
+	if layer != nil {
+		layer.MovementID = movement.ID
+		if err := tx.Create(layer).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Model(&stock).Update("quantity", stock.Quantity+delta).Error; err != nil {
+		return nil, err
+	}
+
+	if err := tx.Model(&product).Updates(map[string]interface{}{
+		"stock":        product.Stock + delta,
+		"average_cost": product.AverageCost,
+	}).Error; err != nil {
+		return nil, err
+	}
+	product.Stock += delta
+
+	return &product, nil
+}
+
+// respondMovementError traduce los errores de applyMovement a respuestas HTTP
+func respondMovementError(c *gin.Context, err error) {
+	var stockErr *insufficientStockError
+	var lockedErr *periodLockedError
+	switch {
+	case errors.Is(err, errProductNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+	case errors.Is(err, errLocationNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La ubicación especificada no existe"})
+	case errors.As(err, &lockedErr):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":         "El período está cerrado; no se pueden registrar movimientos con esa fecha",
+			"cerrado_hasta": lockedErr.Until,
+		})
+	case errors.As(err, &stockErr):
+		response := gin.H{
+			"error":           "Stock insuficiente",
+			"stock_actual":    stockErr.Stock,
+			"cantidad_salida": stockErr.Requested,
+		}
+		if stockErr.Reserved > 0 {
+			response["error"] = "Stock disponible insuficiente: parte del stock está reservado"
+			response["reservado"] = stockErr.Reserved
+			response["disponible"] = stockErr.Stock - stockErr.Reserved
+		}
+		c.JSON(http.StatusBadRequest, response)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al registrar movimiento"})
+	}
+}
@@ -0,0 +1,188 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Stormdead/inventory-control-panel/backend/config"
+	"github.com/Stormdead/inventory-control-panel/backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// normalizeTaxID limpia el identificador fiscal; vacío se guarda como nulo
+func normalizeTaxID(taxID *string) *string {
+	if taxID == nil {
+		return nil
+	}
+	value := strings.TrimSpace(*taxID)
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// taxIDInUse indica si otro proveedor ya usa el identificador fiscal
+func taxIDInUse(taxID *string, excludeID uint) bool {
+	if taxID == nil {
+		return false
+	}
+	var count int64
+	config.DB.Model(&models.Supplier{}).Where("tax_id = ? AND id <> ?", *taxID, excludeID).Count(&count)
+	return count > 0
+}
+
+// GET /api/suppliers - Listar proveedores (?active=true para solo activos, ?search= por nombre)
+func GetSuppliers(c *gin.Context) {
+	var suppliers []models.Supplier
+
+	query := config.DB.Order("name")
+	if c.Query("active") == "true" {
+		query = query.Where("active = ?", true)
+	}
+	if search := strings.TrimSpace(c.Query("search")); search != "" {
+		query = query.Where("name LIKE ? OR tax_id = ?", "%"+search+"%", search)
+	}
+
+	if err := query.Find(&suppliers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener proveedores"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"suppliers": suppliers,
+		"total":     len(suppliers),
+	})
+}
+
+// GET /api/suppliers/:id - Obtener un proveedor por ID
+func GetSupplier(c *gin.Context) {
+	id := c.Param("id")
+	var supplier models.Supplier
+
+	if err := config.DB.First(&supplier, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Proveedor no encontrado"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"supplier": supplier,
+	})
+}
+
+// POST /api/suppliers - Crear un proveedor (solo admin)
+func CreateSupplier(c *gin.Context) {
+	var supplier models.Supplier
+
+	if err := c.ShouldBindJSON(&supplier); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	supplier.Name = strings.TrimSpace(supplier.Name)
+	if supplier.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El nombre es requerido"})
+		return
+	}
+
+	supplier.TaxID = normalizeTaxID(supplier.TaxID)
+	if taxIDInUse(supplier.TaxID, 0) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Ya existe un proveedor con ese identificador fiscal"})
+		return
+	}
+	supplier.Active = true
+
+	if err := config.DB.Create(&supplier).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear proveedor"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Proveedor creado exitosamente",
+		"supplier": supplier,
+	})
+}
+
+// PUT /api/suppliers/:id - Actualizar un proveedor (solo admin)
+func UpdateSupplier(c *gin.Context) {
+	id := c.Param("id")
+	var supplier models.Supplier
+
+	if err := config.DB.First(&supplier, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Proveedor no encontrado"})
+		return
+	}
+
+	var updateData struct {
+		models.Supplier
+		Active *bool `json:"active"`
+	}
+	if err := c.ShouldBindJSON(&updateData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if name := strings.TrimSpace(updateData.Name); name != "" {
+		supplier.Name = name
+	}
+	if updateData.TaxID != nil {
+		taxID := normalizeTaxID(updateData.TaxID)
+		if taxIDInUse(taxID, supplier.ID) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Ya existe un proveedor con ese identificador fiscal"})
+			return
+		}
+		supplier.TaxID = taxID
+	}
+	if updateData.ContactName != "" {
+		supplier.ContactName = updateData.ContactName
+	}
+	if updateData.Email != "" {
+		supplier.Email = updateData.Email
+	}
+	if updateData.Phone != "" {
+		supplier.Phone = updateData.Phone
+	}
+	if updateData.Address != "" {
+		supplier.Address = updateData.Address
+	}
+	if updateData.Active != nil {
+		supplier.Active = *updateData.Active
+	}
+
+	if err := config.DB.Save(&supplier).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar proveedor"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Proveedor actualizado exitosamente",
+		"supplier": supplier,
+	})
+}
+
+// DELETE /api/suppliers/:id - Eliminar un proveedor sin órdenes de compra (solo admin).
+// Los proveedores con historial se desactivan con PUT active=false.
+func DeleteSupplier(c *gin.Context) {
+	id := c.Param("id")
+	var supplier models.Supplier
+
+	if err := config.DB.First(&supplier, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Proveedor no encontrado"})
+		return
+	}
+
+	var orderCount int64
+	config.DB.Model(&models.PurchaseOrder{}).Where("supplier_id = ?", supplier.ID).Count(&orderCount)
+	if orderCount > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "El proveedor tiene órdenes de compra; desactívelo en su lugar"})
+		return
+	}
+
+	if err := config.DB.Delete(&supplier).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar proveedor"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Proveedor eliminado exitosamente",
+	})
+}
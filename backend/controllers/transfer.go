@@ -0,0 +1,244 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Stormdead/inventory-control-panel/backend/config"
+	"github.com/Stormdead/inventory-control-panel/backend/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TransferRequest struct {
+	ProductID      uint   `json:"product_id" binding:"required"`
+	FromLocationID uint   `json:"from_location_id" binding:"required"`
+	ToLocationID   uint   `json:"to_location_id" binding:"required"`
+	Quantity       int    `json:"quantity" binding:"required,gt=0"`
+	Description    string `json:"description"`
+	InTransit      bool   `json:"in_transit"` // Si es true, la entrada se registra al recibir
+}
+
+// loadTransfer carga una transferencia con sus relaciones para la respuesta
+func loadTransfer(transfer *models.Transfer) {
+	config.DB.Preload("Product").Preload("FromLocation").Preload("ToLocation").Preload("User", unscoped).
+		Preload("Movements", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		First(transfer, transfer.ID)
+}
+
+// transferLeg construye el movimiento de uno de los tramos de una transferencia
+func transferLeg(transfer *models.Transfer, movementType string, locationID uint, userID uint) models.Movement {
+	return models.Movement{
+		ProductID:    transfer.ProductID,
+		UserID:       userID,
+		LocationID:   &locationID,
+		Type:         movementType,
+		Quantity:     transfer.Quantity,
+		Description:  fmt.Sprintf("Transferencia #%d", transfer.ID),
+		TransferID:   &transfer.ID,
+		MovementDate: time.Now(),
+	}
+}
+
+// GET /api/transfers - Listar transferencias (filtro opcional ?status=)
+func GetTransfers(c *gin.Context) {
+	var transfers []models.Transfer
+
+	query := config.DB.Preload("Product").Preload("FromLocation").Preload("ToLocation").Preload("User", unscoped)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Order("dispatched_at DESC").Find(&transfers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener transferencias"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"transfers": transfers,
+		"total":     len(transfers),
+	})
+}
+
+// GET /api/transfers/:id - Obtener una transferencia con sus movimientos
+func GetTransfer(c *gin.Context) {
+	id := c.Param("id")
+	var transfer models.Transfer
+
+	if err := config.DB.First(&transfer, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transferencia no encontrada"})
+		return
+	}
+
+	loadTransfer(&transfer)
+
+	c.JSON(http.StatusOK, gin.H{
+		"transfer": transfer,
+	})
+}
+
+// POST /api/transfers - Despachar una transferencia entre ubicaciones
+func CreateTransfer(c *gin.Context) {
+	var req TransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.FromLocationID == req.ToLocationID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El origen y el destino deben ser distintos"})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	var destinationCount int64
+	config.DB.Model(&models.Location{}).Where("id = ?", req.ToLocationID).Count(&destinationCount)
+	if destinationCount == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La ubicación de destino no existe"})
+		return
+	}
+
+	transfer := models.Transfer{
+		ProductID:      req.ProductID,
+		FromLocationID: req.FromLocationID,
+		ToLocationID:   req.ToLocationID,
+		Quantity:       req.Quantity,
+		Status:         models.TransferStatusEnTransito,
+		Description:    req.Description,
+		UserID:         userID.(uint),
+		DispatchedAt:   time.Now(),
+	}
+
+	// Ambos tramos se registran en la misma transacción que la transferencia
+	tx := config.DB.Begin()
+
+	if err := tx.Create(&transfer).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear transferencia"})
+		return
+	}
+
+	dispatch := transferLeg(&transfer, models.MovementTypeTransferSalida, transfer.FromLocationID, transfer.UserID)
+	if _, err := applyMovement(tx, &dispatch); err != nil {
+		tx.Rollback()
+		respondMovementError(c, err)
+		return
+	}
+
+	if !req.InTransit {
+		receipt := transferLeg(&transfer, models.MovementTypeTransferEntrada, transfer.ToLocationID, transfer.UserID)
+		if _, err := applyMovement(tx, &receipt); err != nil {
+			tx.Rollback()
+			respondMovementError(c, err)
+			return
+		}
+
+		transfer.Status = models.TransferStatusRecibida
+		transfer.ReceivedByID = &transfer.UserID
+		transfer.ReceivedAt = &receipt.MovementDate
+		if err := tx.Save(&transfer).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear transferencia"})
+			return
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear transferencia"})
+		return
+	}
+
+	loadTransfer(&transfer)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Transferencia registrada exitosamente",
+		"transfer": transfer,
+	})
+}
+
+// completeTransfer registra el tramo final de una transferencia en tránsito:
+// la entrada al destino (recibir) o la devolución al origen (cancelar)
+func completeTransfer(c *gin.Context, status string) {
+	id := c.Param("id")
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+	uid := userID.(uint)
+
+	tx := config.DB.Begin()
+
+	// Bloquear la transferencia para que no se reciba o cancele dos veces
+	var transfer models.Transfer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&transfer, id).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transferencia no encontrada"})
+		return
+	}
+
+	if transfer.Status != models.TransferStatusEnTransito {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": "La transferencia no está en tránsito"})
+		return
+	}
+
+	locationID := transfer.ToLocationID
+	if status == models.TransferStatusCancelada {
+		locationID = transfer.FromLocationID
+	}
+
+	leg := transferLeg(&transfer, models.MovementTypeTransferEntrada, locationID, uid)
+	if status == models.TransferStatusCancelada {
+		leg.Description = fmt.Sprintf("Cancelación de la transferencia #%d", transfer.ID)
+	}
+	if _, err := applyMovement(tx, &leg); err != nil {
+		tx.Rollback()
+		respondMovementError(c, err)
+		return
+	}
+
+	transfer.Status = status
+	transfer.ReceivedByID = &uid
+	transfer.ReceivedAt = &leg.MovementDate
+	if err := tx.Save(&transfer).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar transferencia"})
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar transferencia"})
+		return
+	}
+
+	loadTransfer(&transfer)
+
+	message := "Transferencia recibida exitosamente"
+	if status == models.TransferStatusCancelada {
+		message = "Transferencia cancelada; el stock volvió al origen"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  message,
+		"transfer": transfer,
+	})
+}
+
+// POST /api/transfers/:id/receive - Recibir una transferencia en tránsito
+func ReceiveTransfer(c *gin.Context) {
+	completeTransfer(c, models.TransferStatusRecibida)
+}
+
+// POST /api/transfers/:id/cancel - Cancelar una transferencia en tránsito (solo admin)
+func CancelTransfer(c *gin.Context) {
+	completeTransfer(c, models.TransferStatusCancelada)
+}
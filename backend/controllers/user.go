@@ -0,0 +1,362 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Stormdead/inventory-control-panel/backend/config"
+	"github.com/Stormdead/inventory-control-panel/backend/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CreateUserRequest struct {
+	Username string `json:"username" binding:"required,min=3,max=50"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=6"`
+	Role     string `json:"role" binding:"required"`
+}
+
+// UpdateUserRequest solo modifica los campos presentes
+type UpdateUserRequest struct {
+	Username *string `json:"username" binding:"omitempty,min=3,max=50"`
+	Email    *string `json:"email" binding:"omitempty,email"`
+	Role     *string `json:"role"`
+	Active   *bool   `json:"active"`
+}
+
+type ResetPasswordRequest struct {
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+// unscoped carga una relación incluyendo los registros eliminados, para que el
+// historial (ej. el usuario de un movimiento) siga siendo legible
+func unscoped(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
+
+// ensureOtherAdmin verifica que quede otro admin activo además de userID.
+// Bloquea las filas de admins para que dos cambios simultáneos no dejen el sistema sin ninguno.
+func ensureOtherAdmin(tx *gorm.DB, userID uint) error {
+	var ids []uint
+	if err := tx.Model(&models.User{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("role = ? AND active = ? AND id <> ?", models.RoleAdmin, true, userID).
+		Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return errors.New("Debe quedar al menos un administrador activo")
+	}
+	return nil
+}
+
+// canManageUser indica si el usuario autenticado puede modificar al usuario objetivo:
+// debe tener todos sus permisos, así que solo "*" puede gestionar a un admin
+func canManageUser(c *gin.Context, db *gorm.DB, user models.User) bool {
+	caller := callerRole(c)
+	if caller.HasPermission(models.PermissionAll) {
+		return true
+	}
+	var role models.Role
+	if err := db.Where("name = ?", user.Role).First(&role).Error; err != nil {
+		return false
+	}
+	return caller.Covers(&role)
+}
+
+// GET /api/users - Listar usuarios (paginado; filtros: search, role, active, include_deleted=true)
+func GetUsers(c *gin.Context) {
+	q, err := parseListQuery(c, userSorts, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := config.DB.Model(&models.User{})
+	if c.Query("include_deleted") == "true" {
+		query = query.Unscoped()
+	}
+	if search := strings.TrimSpace(c.Query("search")); search != "" {
+		query = query.Where("users.username LIKE ? OR users.email LIKE ?", "%"+search+"%", "%"+search+"%")
+	}
+	if role := c.Query("role"); role != "" {
+		query = query.Where("users.role = ?", role)
+	}
+	if active := c.Query("active"); active != "" {
+		query = query.Where("users.active = ?", active == "true")
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener usuarios"})
+		return
+	}
+
+	var users []models.User
+
+	if err := q.apply(query, "users.id").Find(&users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener usuarios"})
+		return
+	}
+
+	users, nextCursor := paginate(users, q, userSortValue)
+
+	c.JSON(http.StatusOK, gin.H{
+		"users":       users,
+		"total":       total,
+		"next_cursor": nextCursor,
+	})
+}
+
+// GET /api/users/:id - Obtener un usuario por ID (incluye eliminados)
+func GetUser(c *gin.Context) {
+	var user models.User
+	if err := config.DB.Unscoped().First(&user, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user": user,
+	})
+}
+
+// POST /api/users - Crear un usuario con su rol (solo admin)
+func CreateUser(c *gin.Context) {
+	var req CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if status, err := assignableRole(c, req.Role); err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	// El usuario y el email son únicos también entre los eliminados
+	var existing int64
+	config.DB.Unscoped().Model(&models.User{}).Where("email = ? OR username = ?", req.Email, req.Username).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "El usuario o email ya existe"})
+		return
+	}
+
+	hashedPassword, err := hashPassword(req.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al procesar la contraseña"})
+		return
+	}
+
+	user := models.User{
+		Username: req.Username,
+		Email:    req.Email,
+		Password: hashedPassword,
+		Role:     req.Role,
+	}
+	if err := config.DB.Create(&user).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear usuario"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Usuario creado exitosamente",
+		"user":    user,
+	})
+}
+
+// PUT /api/users/:id - Actualizar usuario, rol o estado (solo admin).
+// Desactivar una cuenta cierra todas sus sesiones de inmediato.
+func UpdateUser(c *gin.Context) {
+	var req UpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Role != nil {
+		if status, err := assignableRole(c, *req.Role); err != nil {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	tx := config.DB.Begin()
+
+	var user models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, c.Param("id")).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+		return
+	}
+	if !canManageUser(c, tx, user) {
+		tx.Rollback()
+		c.JSON(http.StatusForbidden, gin.H{"error": "No puede gestionar a un usuario con permisos que usted no tiene"})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.Username != nil && *req.Username != user.Username {
+		updates["username"] = *req.Username
+	}
+	if req.Email != nil && *req.Email != user.Email {
+		updates["email"] = *req.Email
+	}
+	if req.Role != nil && *req.Role != user.Role {
+		updates["role"] = *req.Role
+	}
+	if req.Active != nil && *req.Active != user.Active {
+		updates["active"] = *req.Active
+	}
+
+	// Quitar el rol de admin o desactivar a un admin no puede dejar el sistema sin admins
+	losesAdmin := user.Role == models.RoleAdmin && user.Active &&
+		(updates["role"] != nil || updates["active"] == false)
+	if losesAdmin {
+		currentUserID, _ := c.Get("user_id")
+		if currentUserID.(uint) == user.ID {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No puede quitarse el rol de admin ni desactivar su propia cuenta"})
+			return
+		}
+		if err := ensureOtherAdmin(tx, user.ID); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if username, ok := updates["username"]; ok {
+		var count int64
+		tx.Unscoped().Model(&models.User{}).Where("username = ? AND id <> ?", username, user.ID).Count(&count)
+		if count > 0 {
+			tx.Rollback()
+			c.JSON(http.StatusConflict, gin.H{"error": "El nombre de usuario ya existe"})
+			return
+		}
+	}
+	if email, ok := updates["email"]; ok {
+		var count int64
+		tx.Unscoped().Model(&models.User{}).Where("email = ? AND id <> ?", email, user.ID).Count(&count)
+		if count > 0 {
+			tx.Rollback()
+			c.JSON(http.StatusConflict, gin.H{"error": "El email ya existe"})
+			return
+		}
+	}
+
+	if len(updates) > 0 {
+		if err := tx.Model(&user).Updates(updates).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar usuario"})
+			return
+		}
+	}
+
+	if updates["active"] == false {
+		if err := revokeUserSessions(tx, user.ID); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al cerrar las sesiones del usuario"})
+			return
+		}
+	}
+
+	tx.Commit()
+
+	config.DB.First(&user, user.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Usuario actualizado exitosamente",
+		"user":    user,
+	})
+}
+
+// POST /api/users/:id/password - Restablecer la contraseña de un usuario (solo admin).
+// Cierra todas sus sesiones para que deba ingresar con la nueva contraseña.
+func ResetUserPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+		return
+	}
+	if !canManageUser(c, config.DB, user) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "No puede gestionar a un usuario con permisos que usted no tiene"})
+		return
+	}
+
+	hashedPassword, err := hashPassword(req.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al procesar la contraseña"})
+		return
+	}
+
+	tx := config.DB.Begin()
+
+	if err := tx.Model(&user).Update("password", hashedPassword).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar la contraseña"})
+		return
+	}
+	if err := revokeUserSessions(tx, user.ID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al cerrar las sesiones del usuario"})
+		return
+	}
+
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{"message": "Contraseña restablecida exitosamente"})
+}
+
+// DELETE /api/users/:id - Eliminar un usuario (soft delete, solo admin).
+// Sus movimientos y documentos siguen mostrando quién los registró.
+func DeleteUser(c *gin.Context) {
+	tx := config.DB.Begin()
+
+	var user models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, c.Param("id")).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+		return
+	}
+
+	currentUserID, _ := c.Get("user_id")
+	if currentUserID.(uint) == user.ID {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No puede eliminar su propia cuenta"})
+		return
+	}
+	if !canManageUser(c, tx, user) {
+		tx.Rollback()
+		c.JSON(http.StatusForbidden, gin.H{"error": "No puede gestionar a un usuario con permisos que usted no tiene"})
+		return
+	}
+	if user.Role == models.RoleAdmin && user.Active {
+		if err := ensureOtherAdmin(tx, user.ID); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if err := revokeUserSessions(tx, user.ID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al cerrar las sesiones del usuario"})
+		return
+	}
+	if err := tx.Delete(&user).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar usuario"})
+		return
+	}
+
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{"message": "Usuario eliminado exitosamente"})
+}
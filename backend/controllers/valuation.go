@@ -0,0 +1,209 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+
+	"github.com/Stormdead/inventory-control-panel/backend/config"
+	"github.com/Stormdead/inventory-control-panel/backend/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// roundCost redondea un costo a la precisión guardada en la base (4 decimales)
+func roundCost(value float64) float64 {
+	return math.Round(value*10000) / 10000
+}
+
+// costMovement calcula el costo de un movimiento por costo promedio y por FIFO, y
+// actualiza el costo promedio del producto. Se llama desde applyMovement, con la fila
+// del producto bloqueada y antes de aplicar el movimiento al stock.
+// Para los ingresos devuelve la capa FIFO a crear una vez guardado el movimiento.
+func costMovement(tx *gorm.DB, product *models.Product, movement *models.Movement) (*models.CostLayer, error) {
+	quantity := float64(movement.Quantity)
+
+	if models.MovementSign(movement.Type) > 0 {
+		averageUnit, fifoUnit, err := inboundUnitCost(tx, product, movement)
+		if err != nil {
+			return nil, err
+		}
+
+		movement.UnitCost = &averageUnit
+		movement.CostAmount = roundCost(quantity * averageUnit)
+		movement.FifoCost = roundCost(quantity * fifoUnit)
+
+		total := product.Stock + movement.Quantity
+		if total > 0 {
+			product.AverageCost = roundCost((float64(product.Stock)*product.AverageCost + movement.CostAmount) / float64(total))
+		}
+
+		return &models.CostLayer{
+			ProductID: product.ID,
+			Quantity:  movement.Quantity,
+			Remaining: movement.Quantity,
+			UnitCost:  fifoUnit,
+		}, nil
+	}
+
+	// Las salidas se valúan al costo promedio vigente, salvo el reverso de una
+	// entrada, que retira las unidades al costo con el que ingresaron
+	averageUnit := product.AverageCost
+	var preferredLayer uint
+	if movement.Type == models.MovementTypeReversoEntrada && movement.ReversalOfID != nil {
+		var original models.Movement
+		if err := tx.First(&original, *movement.ReversalOfID).Error; err != nil {
+			return nil, err
+		}
+		if original.Quantity > 0 {
+			averageUnit = original.CostAmount / float64(original.Quantity)
+		}
+		preferredLayer = original.ID
+	}
+
+	fifoCost, err := consumeCostLayers(tx, product, movement.Quantity, preferredLayer)
+	if err != nil {
+		return nil, err
+	}
+
+	movement.UnitCost = &averageUnit
+	movement.CostAmount = roundCost(quantity * averageUnit)
+	movement.FifoCost = roundCost(fifoCost)
+
+	remaining := product.Stock - movement.Quantity
+	if remaining > 0 && averageUnit != product.AverageCost {
+		product.AverageCost = roundCost(math.Max(0, (float64(product.Stock)*product.AverageCost-movement.CostAmount)/float64(remaining)))
+	}
+
+	return nil, nil
+}
+
+// inboundUnitCost devuelve el costo unitario de un ingreso por costo promedio y por FIFO.
+// Los reversos de salida y las recepciones de transferencias reingresan las unidades al
+// costo con el que salieron; el resto usa el costo informado o el costo promedio vigente.
+func inboundUnitCost(tx *gorm.DB, product *models.Product, movement *models.Movement) (float64, float64, error) {
+	var source models.Movement
+	switch {
+	case movement.Type == models.MovementTypeReversoSalida && movement.ReversalOfID != nil:
+		if err := tx.First(&source, *movement.ReversalOfID).Error; err != nil {
+			return 0, 0, err
+		}
+	case movement.Type == models.MovementTypeTransferEntrada && movement.TransferID != nil:
+		if err := tx.Where("transfer_id = ? AND type = ?", *movement.TransferID, models.MovementTypeTransferSalida).
+			First(&source).Error; err != nil {
+			return 0, 0, err
+		}
+	default:
+		if movement.UnitCost != nil {
+			return *movement.UnitCost, *movement.UnitCost, nil
+		}
+		return product.AverageCost, product.AverageCost, nil
+	}
+
+	if source.Quantity == 0 {
+		return 0, 0, nil
+	}
+	return source.CostAmount / float64(source.Quantity), source.FifoCost / float64(source.Quantity), nil
+}
+
+// consumeCostLayers descuenta quantity unidades de las capas FIFO del producto, empezando
+// por la capa creada por preferredMovement (si se indica) y luego por las más antiguas.
+// Las unidades sin capa (stock anterior a la valuación) se valúan al costo promedio.
+func consumeCostLayers(tx *gorm.DB, product *models.Product, quantity int, preferredMovement uint) (float64, error) {
+	var layers []models.CostLayer
+	query := tx.Where("product_id = ? AND remaining > 0", product.ID)
+	if preferredMovement != 0 {
+		query = query.Order(fmt.Sprintf("movement_id = %d DESC", preferredMovement))
+	}
+	if err := query.Order("id ASC").Find(&layers).Error; err != nil {
+		return 0, err
+	}
+
+	cost := 0.0
+	for _, layer := range layers {
+		if quantity == 0 {
+			break
+		}
+		used := layer.Remaining
+		if used > quantity {
+			used = quantity
+		}
+		if err := tx.Model(&layer).Update("remaining", layer.Remaining-used).Error; err != nil {
+			return 0, err
+		}
+		cost += float64(used) * layer.UnitCost
+		quantity -= used
+	}
+
+	return cost + float64(quantity)*product.AverageCost, nil
+}
+
+// sortPositionsByValue ordena las posiciones de mayor a menor valor según el método
+func sortPositionsByValue(positions []stockPosition, method string) {
+	sort.SliceStable(positions, func(i, j int) bool {
+		if method == models.ValuationFIFO {
+			return positions[i].FifoValue > positions[j].FifoValue
+		}
+		return positions[i].Value > positions[j].Value
+	})
+}
+
+// GET /api/dashboard/valuation - Valuación del inventario a una fecha
+// (?as_of=YYYY-MM-DD&method=promedio|fifo&category_id=&location_id=)
+func GetInventoryValuation(c *gin.Context) {
+	method := c.DefaultQuery("method", models.ValuationAverage)
+	if method != models.ValuationAverage && method != models.ValuationFIFO {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Método inválido; use promedio o fifo"})
+		return
+	}
+
+	asOf, err := parseAsOf(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	type ValuationLine struct {
+		ProductID    uint    `json:"product_id"`
+		SKU          *string `json:"sku"`
+		ProductName  string  `json:"product_name"`
+		CategoryName *string `json:"category_name"`
+		Quantity     int     `json:"quantity"`
+		Value        float64 `json:"value"`
+		UnitCost     float64 `json:"unit_cost"`
+	}
+
+	// La cantidad y el valor a la fecha salen del libro de movimientos (ver stockAsOf).
+	// Las unidades en tránsito no se valúan.
+	positions, _, err := stockAsOf(config.DB, asOf, c.Query("location_id"), c.Query("category_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al calcular la valuación"})
+		return
+	}
+	sortPositionsByValue(positions, method)
+
+	lines := make([]ValuationLine, len(positions))
+	totalQuantity, totalValue := 0, 0.0
+	for i, p := range positions {
+		value := p.Value
+		if method == models.ValuationFIFO {
+			value = p.FifoValue
+		}
+		lines[i] = ValuationLine{ProductID: p.ProductID, SKU: p.SKU, ProductName: p.ProductName,
+			CategoryName: p.CategoryName, Quantity: p.Quantity, Value: value}
+		if p.Quantity != 0 {
+			lines[i].UnitCost = roundCost(value / float64(p.Quantity))
+		}
+		totalQuantity += p.Quantity
+		totalValue += value
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"method":         method,
+		"as_of":          asOf,
+		"products":       lines,
+		"total_quantity": totalQuantity,
+		"total_value":    roundCost(totalValue),
+	})
+}
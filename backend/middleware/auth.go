@@ -3,7 +3,10 @@ package middleware
 import (
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/Stormdead/inventory-control-panel/backend/config"
+	"github.com/Stormdead/inventory-control-panel/backend/models"
 	"github.com/Stormdead/inventory-control-panel/backend/utils"
 	"github.com/gin-gonic/gin"
 )
@@ -37,21 +40,65 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Guardar información del usuario en el contexto
-		c.Set("user_id", claims.UserID)
-		c.Set("username", claims.Username)
-		c.Set("role", claims.Role)
+		// La sesión debe seguir viva y la cuenta activa: un logout, una revocación o
+		// una desactivación invalidan el token de inmediato
+		user, ok := sessionUser(claims)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Sesión revocada o expirada"})
+			c.Abort()
+			return
+		}
+
+		// Guardar información del usuario en el contexto. El rol se toma de la base
+		// para que un cambio de rol aplique sin esperar a que expire el token.
+		c.Set("user_id", user.ID)
+		c.Set("username", user.Username)
+		c.Set("role", user.Role)
+		c.Set("session_id", claims.SessionID)
+
+		// Los permisos también se leen en cada petición: editar un rol aplica de inmediato
+		var role models.Role
+		if err := config.DB.Where("name = ?", user.Role).First(&role).Error; err == nil {
+			c.Set("permissions", &role)
+		}
 
 		c.Next()
 	}
 }
 
-// Middleware para verificar rol de administrador
-func AdminMiddleware() gin.HandlerFunc {
+// sessionUser devuelve el usuario del token si está activo y la familia de refresh
+// tokens de su sesión conserva uno vigente
+func sessionUser(claims *utils.Claims) (*models.User, bool) {
+	if claims.SessionID == "" {
+		return nil, false
+	}
+
+	var user models.User
+	err := config.DB.
+		Where("users.id = ? AND users.active = ?", claims.UserID, true).
+		Where(`EXISTS (SELECT 1 FROM refresh_tokens rt WHERE rt.user_id = users.id AND rt.family_id = ?
+			AND rt.revoked_at IS NULL AND rt.used_at IS NULL AND rt.expires_at > ?)`, claims.SessionID, time.Now()).
+		First(&user).Error
+	if err != nil {
+		return nil, false
+	}
+	return &user, true
+}
+
+// HasPermission indica si el rol del usuario autenticado incluye el permiso
+func HasPermission(c *gin.Context, permission string) bool {
+	value, exists := c.Get("permissions")
+	if !exists {
+		return false
+	}
+	return value.(*models.Role).HasPermission(permission)
+}
+
+// Middleware para exigir un permiso al rol del usuario (usar después de AuthMiddleware)
+func RequirePermission(permission string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		role, exists := c.Get("role")
-		if !exists || role != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Acceso denegado. Se requiere rol de administrador"})
+		if !HasPermission(c, permission) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Acceso denegado. Se requiere el permiso " + permission})
 			c.Abort()
 			return
 		}
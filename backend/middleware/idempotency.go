@@ -0,0 +1,174 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/Stormdead/inventory-control-panel/backend/config"
+	"github.com/Stormdead/inventory-control-panel/backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Ventana por defecto durante la que se conserva una Idempotency-Key
+const defaultIdempotencyTTL = 24 * time.Hour
+
+// idempotencyTTL lee la ventana de IDEMPOTENCY_TTL (ej. "24h", "30m")
+func idempotencyTTL() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv("IDEMPOTENCY_TTL")); err == nil && ttl > 0 {
+		return ttl
+	}
+	return defaultIdempotencyTTL
+}
+
+// Intervalo mínimo entre dos limpiezas de claves vencidas
+const idempotencySweepInterval = 10 * time.Minute
+
+// Momento (UnixNano) de la última limpieza de claves vencidas
+var lastIdempotencySweep atomic.Int64
+
+// sweepExpiredIdempotencyKeys borra las claves vencidas a lo sumo una vez por intervalo,
+// para no ejecutar un DELETE en cada petición
+func sweepExpiredIdempotencyKeys(now time.Time) {
+	last := lastIdempotencySweep.Load()
+	if now.UnixNano()-last < int64(idempotencySweepInterval) ||
+		!lastIdempotencySweep.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+	config.DB.Where("expires_at < ?", now).Delete(&models.IdempotencyKey{})
+}
+
+// responseRecorder copia el cuerpo de la respuesta mientras se escribe al cliente
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Middleware para peticiones idempotentes con la cabecera Idempotency-Key.
+// Una petición repetida con la misma clave devuelve la respuesta original sin
+// volver a ejecutar el handler. Debe ir después de AuthMiddleware.
+func Idempotency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		if len(key) > 255 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key demasiado larga (máximo 255 caracteres)"})
+			c.Abort()
+			return
+		}
+
+		userID, _ := c.Get("user_id")
+		uid, _ := userID.(uint)
+
+		// Leer el cuerpo para calcular su hash y restaurarlo para el handler
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No se pudo leer el cuerpo de la petición"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
+		sum := sha256.Sum256(body)
+		requestHash := hex.EncodeToString(sum[:])
+
+		// El alcance usa la ruta real (con sus parámetros): la misma clave en
+		// /sales-orders/1/fulfill y /sales-orders/2/fulfill son peticiones distintas
+		now := time.Now()
+		path := c.Request.URL.Path
+		scope := config.DB.Where("user_id = ? AND method = ? AND path = ? AND `key` = ?",
+			uid, c.Request.Method, path, key)
+
+		var record models.IdempotencyKey
+		if err := scope.First(&record).Error; err == nil {
+			if record.ExpiresAt.Before(now) {
+				config.DB.Delete(&record)
+			} else {
+				replayIdempotentResponse(c, &record, requestHash)
+				return
+			}
+		}
+
+		// Limpiar claves vencidas y reservar la nueva
+		sweepExpiredIdempotencyKeys(now)
+
+		record = models.IdempotencyKey{
+			UserID:      uid,
+			Method:      c.Request.Method,
+			Path:        path,
+			Key:         key,
+			RequestHash: requestHash,
+			ExpiresAt:   now.Add(idempotencyTTL()),
+		}
+		if err := config.DB.Create(&record).Error; err != nil {
+			// Otra petición con la misma clave la reservó primero
+			c.JSON(http.StatusConflict, gin.H{"error": "Ya hay una petición en curso con esta Idempotency-Key"})
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		// La reserva se libera si el handler no termina (ej. un panic que responde
+		// gin.Recovery) o falla en el servidor, para permitir reintentos con la misma clave
+		completed := false
+		defer func() {
+			if !completed {
+				config.DB.Delete(&record)
+			}
+		}()
+
+		c.Next()
+
+		// Los errores del servidor no se guardan
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+
+		completed = true
+		config.DB.Model(&record).Updates(map[string]interface{}{
+			"completed":     true,
+			"status_code":   status,
+			"response_body": recorder.body.String(),
+		})
+	}
+}
+
+// replayIdempotentResponse devuelve la respuesta guardada para una clave ya usada
+func replayIdempotentResponse(c *gin.Context, record *models.IdempotencyKey, requestHash string) {
+	if record.RequestHash != requestHash {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "La Idempotency-Key ya se usó con una petición distinta"})
+		c.Abort()
+		return
+	}
+
+	if !record.Completed {
+		c.JSON(http.StatusConflict, gin.H{"error": "Ya hay una petición en curso con esta Idempotency-Key"})
+		c.Abort()
+		return
+	}
+
+	c.Header("Idempotent-Replayed", "true")
+	c.Data(record.StatusCode, "application/json; charset=utf-8", []byte(record.ResponseBody))
+	c.Abort()
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// ProductBarcode es un código de barras de un producto (un producto puede tener varios)
+type ProductBarcode struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ProductID uint      `gorm:"not null;index" json:"product_id"`
+	Code      string    `gorm:"size:64;uniqueIndex;not null" json:"code"`
+	Symbology string    `gorm:"type:enum('ean13','upca','code128');not null" json:"symbology"`
+	CreatedAt time.Time `json:"created_at"`
+}
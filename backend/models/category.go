@@ -3,8 +3,11 @@ package models
 import "time"
 
 type Category struct {
-	ID          uint      `gorm:"primaryKey" json:"id"`
-	Name        string    `gorm:"not null" json:"name"`
-	Description string    `json:"description"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID                  uint      `gorm:"primaryKey" json:"id"`
+	Name                string    `gorm:"not null" json:"name"`
+	Description         string    `json:"description"`
+	DefaultMinStock     *int      `json:"default_min_stock"` // Niveles por defecto de los productos sin niveles propios
+	DefaultMaxStock     *int      `json:"default_max_stock"`
+	DefaultReorderPoint *int      `json:"default_reorder_point"`
+	CreatedAt           time.Time `json:"created_at"`
 }
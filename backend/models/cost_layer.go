@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Métodos de valuación del inventario
+const (
+	ValuationAverage = "promedio" // Costo promedio ponderado
+	ValuationFIFO    = "fifo"     // Primero en entrar, primero en salir
+)
+
+// CostColumn devuelve la columna de movements con el costo según el método de valuación
+func CostColumn(method string) string {
+	if method == ValuationFIFO {
+		return "fifo_cost"
+	}
+	return "cost_amount"
+}
+
+// CostLayer es una capa de costo FIFO: las unidades que ingresaron con un movimiento
+// a un mismo costo unitario. Las salidas consumen primero las capas más antiguas.
+type CostLayer struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	ProductID  uint      `gorm:"not null;index" json:"product_id"`
+	MovementID uint      `gorm:"not null;index" json:"movement_id"` // Movimiento de ingreso que creó la capa
+	Quantity   int       `gorm:"not null" json:"quantity"`
+	Remaining  int       `gorm:"not null" json:"remaining"`
+	UnitCost   float64   `gorm:"type:decimal(14,4);not null" json:"unit_cost"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Estados de una sesión de conteo físico
+const (
+	CountStatusAbierta   = "abierta"
+	CountStatusAprobada  = "aprobada"
+	CountStatusCancelada = "cancelada"
+)
+
+// CountSession es un conteo físico (cycle count) de una ubicación, opcionalmente de una sola categoría.
+// Las cantidades esperadas se congelan al abrir la sesión.
+type CountSession struct {
+	ID           uint        `gorm:"primaryKey" json:"id"`
+	LocationID   uint        `gorm:"not null" json:"location_id"`
+	Location     *Location   `gorm:"foreignKey:LocationID" json:"location,omitempty"`
+	CategoryID   *uint       `json:"category_id"`
+	Category     *Category   `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
+	Status       string      `gorm:"type:enum('abierta','aprobada','cancelada');default:'abierta';index" json:"status"`
+	Description  string      `json:"description"`
+	OpenedByID   uint        `gorm:"not null" json:"opened_by_id"`
+	ApprovedByID *uint       `json:"approved_by_id,omitempty"`
+	OpenedAt     time.Time   `json:"opened_at"`
+	ClosedAt     *time.Time  `json:"closed_at,omitempty"`
+	Lines        []CountLine `gorm:"foreignKey:SessionID" json:"lines,omitempty"`
+}
+
+// CountLine es la cantidad esperada y la contada de un producto dentro de una sesión
+type CountLine struct {
+	ID                   uint       `gorm:"primaryKey" json:"id"`
+	SessionID            uint       `gorm:"not null;uniqueIndex:idx_count_line" json:"session_id"`
+	ProductID            uint       `gorm:"not null;uniqueIndex:idx_count_line" json:"product_id"`
+	Product              *Product   `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	ExpectedQuantity     int        `gorm:"not null" json:"expected_quantity"`
+	CountedQuantity      *int       `json:"counted_quantity"`
+	CountedByID          *uint      `json:"counted_by_id,omitempty"`
+	CountedAt            *time.Time `json:"counted_at,omitempty"`
+	AdjustmentMovementID *uint      `json:"adjustment_movement_id,omitempty"`
+}
+
+// Variance es la diferencia entre lo contado y lo esperado (nil si aún no se contó)
+func (l *CountLine) Variance() *int {
+	if l.CountedQuantity == nil {
+		return nil
+	}
+	variance := *l.CountedQuantity - l.ExpectedQuantity
+	return &variance
+}
+
+// MarshalJSON incluye la diferencia calculada en la respuesta
+func (l CountLine) MarshalJSON() ([]byte, error) {
+	type line CountLine
+	return json.Marshal(struct {
+		line
+		Variance *int `json:"variance"`
+	}{line(l), l.Variance()})
+}
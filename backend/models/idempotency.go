@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// IdempotencyKey guarda la respuesta original de una petición con cabecera Idempotency-Key
+type IdempotencyKey struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	UserID       uint      `gorm:"not null;uniqueIndex:idx_idempotency_scope" json:"user_id"`
+	Method       string    `gorm:"size:10;not null;uniqueIndex:idx_idempotency_scope" json:"method"`
+	Path         string    `gorm:"size:255;not null;uniqueIndex:idx_idempotency_scope" json:"path"`
+	Key          string    `gorm:"size:255;not null;uniqueIndex:idx_idempotency_scope" json:"key"`
+	RequestHash  string    `gorm:"size:64;not null" json:"-"`
+	Completed    bool      `gorm:"default:false" json:"completed"`
+	StatusCode   int       `json:"status_code"`
+	ResponseBody string    `gorm:"type:longtext" json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+	ExpiresAt    time.Time `gorm:"index" json:"expires_at"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Invitation permite a un admin dar de alta a un usuario con un rol. El invitado
+// define su usuario y contraseña con un token de un solo uso (solo se guarda su hash).
+type Invitation struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	Email          string     `gorm:"size:255;not null;index" json:"email"`
+	Role           string     `gorm:"size:50;not null;default:'employee'" json:"role"` // Name de un Role
+	TokenHash      string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	ExpiresAt      time.Time  `gorm:"not null" json:"expires_at"`
+	InvitedByID    uint       `gorm:"not null" json:"invited_by_id"`
+	InvitedBy      *User      `gorm:"foreignKey:InvitedByID" json:"invited_by,omitempty"`
+	AcceptedAt     *time.Time `json:"accepted_at"`
+	AcceptedUserID *uint      `json:"accepted_user_id"`
+	RevokedAt      *time.Time `json:"revoked_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	Status         string     `gorm:"-" json:"status"` // Calculado con CurrentStatus al responder
+}
+
+// Estados de una invitación (no se guardan; se calculan con CurrentStatus)
+const (
+	InvitationStatusPendiente = "pendiente"
+	InvitationStatusAceptada  = "aceptada"
+	InvitationStatusRevocada  = "revocada"
+	InvitationStatusExpirada  = "expirada"
+)
+
+// CurrentStatus devuelve el estado de la invitación a la fecha now
+func (i Invitation) CurrentStatus(now time.Time) string {
+	switch {
+	case i.AcceptedAt != nil:
+		return InvitationStatusAceptada
+	case i.RevokedAt != nil:
+		return InvitationStatusRevocada
+	case now.After(i.ExpiresAt):
+		return InvitationStatusExpirada
+	default:
+		return InvitationStatusPendiente
+	}
+}
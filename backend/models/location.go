@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Location es un almacén o tienda donde se guarda stock
+type Location struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"not null" json:"name"`
+	Code      string    `gorm:"size:20;uniqueIndex;not null" json:"code"`
+	Type      string    `gorm:"type:enum('almacen','tienda');default:'almacen'" json:"type"`
+	Address   string    `json:"address"`
+	IsDefault bool      `gorm:"default:false" json:"is_default"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ProductStock es el stock de un producto en una ubicación.
+// Product.Stock es la suma de estas filas y se mantiene por compatibilidad.
+type ProductStock struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	ProductID  uint      `gorm:"not null;uniqueIndex:idx_product_location" json:"product_id"`
+	Product    *Product  `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	LocationID uint      `gorm:"not null;uniqueIndex:idx_product_location" json:"location_id"`
+	Location   *Location `gorm:"foreignKey:LocationID" json:"location,omitempty"`
+	Quantity   int       `gorm:"default:0" json:"quantity"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
@@ -1,15 +1,137 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Tipos de movimiento
+const (
+	MovementTypeEntrada             = "entrada"
+	MovementTypeSalida              = "salida"
+	MovementTypeReversoEntrada      = "reverso_entrada"       // Anula una entrada (resta stock)
+	MovementTypeReversoSalida       = "reverso_salida"        // Anula una salida (suma stock)
+	MovementTypeAjusteEntrada       = "ajuste_entrada"        // Ajuste positivo (reconciliación, conteos)
+	MovementTypeAjusteSalida        = "ajuste_salida"         // Ajuste negativo (reconciliación, conteos)
+	MovementTypeTransferSalida      = "transferencia_salida"  // Despacho de una transferencia desde el origen
+	MovementTypeTransferEntrada     = "transferencia_entrada" // Recepción de una transferencia en el destino
+	MovementTypeBaja                = "baja"                  // Baja por daño, robo o vencimiento
+	MovementTypeDevolucionProveedor = "devolucion_proveedor"  // Devolución de mercadería al proveedor
+	MovementTypeDevolucionCliente   = "devolucion_cliente"    // Devolución de un cliente
+)
+
+// Tipos que suman o restan stock
+var (
+	InboundMovementTypes = []string{MovementTypeEntrada, MovementTypeReversoSalida, MovementTypeAjusteEntrada,
+		MovementTypeTransferEntrada, MovementTypeDevolucionCliente}
+	OutboundMovementTypes = []string{MovementTypeSalida, MovementTypeReversoEntrada, MovementTypeAjusteSalida,
+		MovementTypeTransferSalida, MovementTypeBaja, MovementTypeDevolucionProveedor}
+)
+
+// Tipos que se pueden registrar directamente en POST /api/movements.
+// Todos salvo entrada y salida requieren un motivo del catálogo.
+var ManualMovementTypes = []string{MovementTypeEntrada, MovementTypeSalida, MovementTypeAjusteEntrada,
+	MovementTypeAjusteSalida, MovementTypeBaja, MovementTypeDevolucionProveedor, MovementTypeDevolucionCliente}
 
 type Movement struct {
-	ID           uint      `gorm:"primaryKey" json:"id"`
-	ProductID    uint      `gorm:"not null" json:"product_id"`
-	Product      Product   `gorm:"foreignKey:ProductID" json:"product,omitempty"`
-	UserID       uint      `gorm:"not null" json:"user_id"`
-	User         User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
-	Type         string    `gorm:"type:enum('entrada','salida');not null" json:"type"`
-	Quantity     int       `gorm:"not null" json:"quantity"`
-	Description  string    `json:"description"`
-	MovementDate time.Time `gorm:"autoCreateTime" json:"movement_date"`
+	ID                  uint        `gorm:"primaryKey" json:"id"`
+	ProductID           uint        `gorm:"not null" json:"product_id"`
+	Product             Product     `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	UserID              uint        `gorm:"not null" json:"user_id"`
+	User                User        `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	LocationID          *uint       `gorm:"index" json:"location_id"`
+	Location            *Location   `gorm:"foreignKey:LocationID" json:"location,omitempty"`
+	Type                string      `gorm:"type:enum('entrada','salida','reverso_entrada','reverso_salida','ajuste_entrada','ajuste_salida','transferencia_salida','transferencia_entrada','baja','devolucion_proveedor','devolucion_cliente');not null" json:"type"`
+	Quantity            int         `gorm:"not null" json:"quantity"`
+	Description         string      `json:"description"`
+	ReasonCodeID        *uint       `gorm:"index" json:"reason_code_id,omitempty"`
+	ReasonCode          *ReasonCode `gorm:"foreignKey:ReasonCodeID" json:"reason_code,omitempty"`
+	ReversalOfID        *uint       `gorm:"uniqueIndex" json:"reversal_of_id,omitempty"`
+	TransferID          *uint       `gorm:"index" json:"transfer_id,omitempty"`
+	PurchaseOrderLineID *uint       `gorm:"index" json:"purchase_order_line_id,omitempty"`
+	SalesOrderLineID    *uint       `gorm:"index" json:"sales_order_line_id,omitempty"`
+	UnitCost            *float64    `gorm:"type:decimal(14,4)" json:"unit_cost,omitempty"`   // Costo unitario; se informa en las entradas
+	CostAmount          float64     `gorm:"type:decimal(16,4);default:0" json:"cost_amount"` // Costo total a costo promedio (en salidas, el costo de venta)
+	FifoCost            float64     `gorm:"type:decimal(16,4);default:0" json:"fifo_cost"`   // Costo total según las capas FIFO
+	MovementDate        time.Time   `gorm:"autoCreateTime" json:"movement_date"`
+}
+
+// MovementSign indica cómo afecta un tipo de movimiento al stock (+1 suma, -1 resta)
+func MovementSign(movementType string) int {
+	for _, t := range InboundMovementTypes {
+		if t == movementType {
+			return 1
+		}
+	}
+	for _, t := range OutboundMovementTypes {
+		if t == movementType {
+			return -1
+		}
+	}
+	return 0
+}
+
+// StockDeltaSQL devuelve una expresión SQL con el efecto de un movimiento sobre el stock.
+// alias es el alias de la tabla movements en la consulta (ej. "m").
+func StockDeltaSQL(alias string) string {
+	return signedSQL(alias, "quantity")
+}
+
+// CostDeltaSQL devuelve una expresión SQL con el efecto de un movimiento sobre el valor
+// del inventario. column es la columna de costo (ver CostColumn).
+func CostDeltaSQL(alias, column string) string {
+	return signedSQL(alias, column)
+}
+
+// ConsumptionSQL devuelve una condición SQL que selecciona el consumo: las salidas
+// comerciales que no fueron revertidas. alias es el alias de la tabla movements.
+func ConsumptionSQL(alias string) string {
+	return fmt.Sprintf("%[1]s.type = '%[2]s' AND NOT EXISTS (SELECT 1 FROM movements rv WHERE rv.reversal_of_id = %[1]s.id)",
+		alias, MovementTypeSalida)
+}
+
+func signedSQL(alias, column string) string {
+	return fmt.Sprintf("CASE WHEN %[1]s.type IN ('%[3]s') THEN %[1]s.%[2]s WHEN %[1]s.type IN ('%[4]s') THEN -%[1]s.%[2]s ELSE 0 END",
+		alias, column, strings.Join(InboundMovementTypes, "','"), strings.Join(OutboundMovementTypes, "','"))
+}
+
+// ReversalType devuelve el tipo de movimiento que compensa al tipo dado
+func ReversalType(movementType string) string {
+	if MovementSign(movementType) > 0 {
+		return MovementTypeReversoEntrada
+	}
+	return MovementTypeReversoSalida
+}
+
+// IsManualType indica si el tipo se puede registrar directamente como movimiento
+func IsManualType(movementType string) bool {
+	for _, t := range ManualMovementTypes {
+		if t == movementType {
+			return true
+		}
+	}
+	return false
+}
+
+// RequiresReasonCode indica si el tipo de movimiento necesita un motivo del catálogo
+func RequiresReasonCode(movementType string) bool {
+	return IsManualType(movementType) && movementType != MovementTypeEntrada && movementType != MovementTypeSalida
+}
+
+// RespectsReservations indica si el tipo de salida solo puede usar el stock no reservado.
+// Los ajustes y bajas reflejan lo que pasó físicamente y no se bloquean por reservas.
+func RespectsReservations(movementType string) bool {
+	return movementType == MovementTypeSalida || movementType == MovementTypeTransferSalida ||
+		movementType == MovementTypeDevolucionProveedor
+}
+
+// IsTransfer indica si el tipo corresponde a un tramo de transferencia entre ubicaciones
+func IsTransfer(movementType string) bool {
+	return movementType == MovementTypeTransferSalida || movementType == MovementTypeTransferEntrada
+}
+
+// IsReversal indica si el tipo corresponde a un movimiento de reverso
+func IsReversal(movementType string) bool {
+	return movementType == MovementTypeReversoEntrada || movementType == MovementTypeReversoSalida
 }
@@ -7,15 +7,25 @@ import (
 )
 
 type Product struct {
-	ID          uint           `gorm:"primaryKey" json:"id"`
-	Name        string         `gorm:"not null" json:"name"`
-	Description string         `json:"description"`
-	CategoryID  *uint          `json:"category_id"`
-	Category    *Category      `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
-	Price       float64        `gorm:"not null" json:"price"`
-	Stock       int            `gorm:"default:0" json:"stock"`
-	ImageURL    string         `json:"image_url"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	ID           uint             `gorm:"primaryKey" json:"id"`
+	Name         string           `gorm:"not null" json:"name"`
+	SKU          *string          `gorm:"size:64;uniqueIndex" json:"sku"`
+	Description  string           `json:"description"`
+	CategoryID   *uint            `json:"category_id"`
+	Category     *Category        `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
+	Price        float64          `gorm:"not null" json:"price"`
+	Stock        int              `gorm:"default:0" json:"stock"`                           // Total de todas las ubicaciones
+	OnOrder      int              `gorm:"-" json:"on_order"`                                // Pendiente de recibir en órdenes de compra abiertas
+	Reserved     int              `gorm:"-" json:"reserved"`                                // Reservado por pedidos de clientes confirmados
+	Available    int              `gorm:"-" json:"available"`                               // Disponible para prometer: Stock - Reserved
+	AverageCost  float64          `gorm:"type:decimal(14,4);default:0" json:"average_cost"` // Costo promedio ponderado
+	MinStock     *int             `json:"min_stock"`                                        // Stock de seguridad (nil: el de la categoría)
+	MaxStock     *int             `json:"max_stock"`                                        // Nivel hasta el que se repone (nil: el de la categoría)
+	ReorderPoint *int             `json:"reorder_point"`                                    // Stock bajo por debajo de este nivel (nil: el de la categoría o el global)
+	Stocks       []ProductStock   `gorm:"foreignKey:ProductID" json:"stocks,omitempty"`
+	Barcodes     []ProductBarcode `gorm:"foreignKey:ProductID" json:"barcodes,omitempty"`
+	ImageURL     string           `json:"image_url"`
+	CreatedAt    time.Time        `json:"created_at"`
+	UpdatedAt    time.Time        `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt   `gorm:"index" json:"-"`
 }
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Estados de una orden de compra
+const (
+	PurchaseOrderStatusAbierta   = "abierta"
+	PurchaseOrderStatusParcial   = "parcial" // Recibida en parte
+	PurchaseOrderStatusRecibida  = "recibida"
+	PurchaseOrderStatusCancelada = "cancelada"
+)
+
+// OpenPurchaseOrderStatuses son los estados con mercadería pendiente de recibir
+var OpenPurchaseOrderStatuses = []string{PurchaseOrderStatusAbierta, PurchaseOrderStatusParcial}
+
+// PurchaseOrder es un pedido a un proveedor. Cada recepción registra movimientos de
+// entrada en la ubicación de destino, vinculados a la línea recibida.
+type PurchaseOrder struct {
+	ID           uint                `gorm:"primaryKey" json:"id"`
+	SupplierID   uint                `gorm:"not null;index" json:"supplier_id"`
+	Supplier     *Supplier           `gorm:"foreignKey:SupplierID" json:"supplier,omitempty"`
+	LocationID   uint                `gorm:"not null" json:"location_id"` // Ubicación donde se recibe
+	Location     *Location           `gorm:"foreignKey:LocationID" json:"location,omitempty"`
+	Status       string              `gorm:"type:enum('abierta','parcial','recibida','cancelada');default:'abierta';index" json:"status"`
+	Reference    string              `json:"reference"` // Número o referencia del proveedor
+	ExpectedDate *time.Time          `json:"expected_date"`
+	Notes        string              `json:"notes"`
+	CreatedByID  uint                `gorm:"not null" json:"created_by_id"`
+	Lines        []PurchaseOrderLine `gorm:"foreignKey:PurchaseOrderID" json:"lines,omitempty"`
+	CreatedAt    time.Time           `json:"created_at"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+}
+
+// PurchaseOrderLine es un producto pedido dentro de una orden de compra
+type PurchaseOrderLine struct {
+	ID               uint       `gorm:"primaryKey" json:"id"`
+	PurchaseOrderID  uint       `gorm:"not null;index" json:"purchase_order_id"`
+	ProductID        uint       `gorm:"not null;index" json:"product_id"`
+	Product          *Product   `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Quantity         int        `gorm:"not null" json:"quantity"`
+	ReceivedQuantity int        `gorm:"default:0" json:"received_quantity"`
+	UnitCost         float64    `gorm:"type:decimal(14,4);default:0" json:"unit_cost"`
+	ExpectedDate     *time.Time `json:"expected_date"` // Si es nula se usa la de la orden
+}
+
+// Pending devuelve la cantidad que falta recibir
+func (l PurchaseOrderLine) Pending() int {
+	if l.ReceivedQuantity >= l.Quantity {
+		return 0
+	}
+	return l.Quantity - l.ReceivedQuantity
+}
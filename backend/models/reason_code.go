@@ -0,0 +1,50 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// ReasonCode es un motivo del catálogo para movimientos no estándar (ajustes, bajas, devoluciones)
+type ReasonCode struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	Code          string    `gorm:"size:30;uniqueIndex;not null" json:"code"`
+	Name          string    `gorm:"not null" json:"name"`
+	Description   string    `json:"description"`
+	MovementTypes string    `json:"movement_types"` // Tipos permitidos separados por coma; vacío = todos
+	Active        bool      `gorm:"default:true" json:"active"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// AllowsType indica si el motivo se puede usar con el tipo de movimiento dado
+func (r *ReasonCode) AllowsType(movementType string) bool {
+	if strings.TrimSpace(r.MovementTypes) == "" {
+		return true
+	}
+	for _, t := range strings.Split(r.MovementTypes, ",") {
+		if strings.TrimSpace(t) == movementType {
+			return true
+		}
+	}
+	return false
+}
+
+// Motivos del sistema usados por ajustes automáticos
+const (
+	ReasonCodeStockInicial   = "STOCK_INICIAL"
+	ReasonCodeReconciliacion = "RECONCILIACION"
+	ReasonCodeConteo         = "CONTEO"
+)
+
+// DefaultReasonCodes es el catálogo inicial
+var DefaultReasonCodes = []ReasonCode{
+	{Code: ReasonCodeStockInicial, Name: "Stock inicial", MovementTypes: MovementTypeAjusteEntrada},
+	{Code: ReasonCodeReconciliacion, Name: "Reconciliación de stock", MovementTypes: MovementTypeAjusteEntrada + "," + MovementTypeAjusteSalida},
+	{Code: ReasonCodeConteo, Name: "Conteo físico", MovementTypes: MovementTypeAjusteEntrada + "," + MovementTypeAjusteSalida},
+	{Code: "DANO", Name: "Producto dañado", MovementTypes: MovementTypeBaja + "," + MovementTypeDevolucionProveedor + "," + MovementTypeDevolucionCliente},
+	{Code: "ROBO", Name: "Robo o extravío", MovementTypes: MovementTypeBaja + "," + MovementTypeAjusteSalida},
+	{Code: "VENCIMIENTO", Name: "Producto vencido", MovementTypes: MovementTypeBaja + "," + MovementTypeDevolucionProveedor},
+	{Code: "ERROR_REGISTRO", Name: "Error de registro", MovementTypes: MovementTypeAjusteEntrada + "," + MovementTypeAjusteSalida},
+	{Code: "INSATISFECHO", Name: "Cliente insatisfecho", MovementTypes: MovementTypeDevolucionCliente},
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// RefreshToken es un token de refresco emitido al iniciar sesión. Solo se guarda el hash.
+// Todos los tokens que nacen de una misma sesión comparten FamilyID: al refrescar, el token
+// usado se marca y se emite uno nuevo en la familia. Reusar un token ya usado revoca la familia.
+type RefreshToken struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	UserID       uint       `gorm:"not null;index" json:"user_id"`
+	FamilyID     string     `gorm:"size:64;not null;index" json:"family_id"`
+	TokenHash    string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	ExpiresAt    time.Time  `gorm:"not null;index" json:"expires_at"`
+	UsedAt       *time.Time `json:"used_at"`
+	ReplacedByID *uint      `json:"replaced_by_id"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
@@ -0,0 +1,148 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Role agrupa permisos con nombre. users.role e invitations.role guardan el Name del rol.
+type Role struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Name        string    `gorm:"size:50;uniqueIndex;not null" json:"name"`
+	Description string    `json:"description"`
+	Permissions string    `gorm:"type:text" json:"permissions"` // Permisos separados por coma; "*" = todos
+	System      bool      `gorm:"default:false" json:"system"`  // Rol del sistema: no se puede eliminar
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// PermissionList devuelve los permisos del rol
+func (r *Role) PermissionList() []string {
+	var permissions []string
+	for _, p := range strings.Split(r.Permissions, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			permissions = append(permissions, p)
+		}
+	}
+	return permissions
+}
+
+// HasPermission indica si el rol incluye el permiso (o todos con "*")
+func (r *Role) HasPermission(permission string) bool {
+	for _, p := range r.PermissionList() {
+		if p == PermissionAll || p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Covers indica si el rol incluye todos los permisos del otro rol. Solo "*" cubre a "*".
+func (r *Role) Covers(other *Role) bool {
+	for _, p := range other.PermissionList() {
+		if !r.HasPermission(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// Roles del sistema
+const (
+	RoleAdmin    = "admin"    // Todos los permisos; no se puede modificar
+	RoleEmployee = "employee" // Rol de los usuarios del registro público
+)
+
+// Permisos con nombre que se asignan a los roles
+const (
+	PermissionAll              = "*"
+	PermissionUsersManage      = "users:manage"
+	PermissionRolesManage      = "roles:manage"
+	PermissionCategoriesWrite  = "categories:write"
+	PermissionLocationsWrite   = "locations:write"
+	PermissionReasonCodesWrite = "reason-codes:write"
+	PermissionProductsWrite    = "products:write"
+	PermissionProductsUpdate   = "products:update"
+	PermissionProductsPrice    = "products:price"
+	PermissionSuppliersWrite   = "suppliers:write"
+	PermissionPurchaseWrite    = "purchase-orders:write"
+	PermissionPurchaseReceive  = "purchase-orders:receive"
+	PermissionSalesWrite       = "sales-orders:write"
+	PermissionSalesFulfill     = "sales-orders:fulfill"
+	PermissionSalesCancel      = "sales-orders:cancel"
+	PermissionTransfersCreate  = "transfers:create"
+	PermissionTransfersReceive = "transfers:receive"
+	PermissionTransfersCancel  = "transfers:cancel"
+	PermissionCountsManage     = "counts:manage"
+	PermissionCountsEnter      = "counts:enter"
+	PermissionMovementsCreate  = "movements:create"
+	PermissionMovementsReverse = "movements:reverse"
+	PermissionStockReconcile   = "stock:reconcile"
+	PermissionStockSnapshots   = "stock:snapshots"
+	PermissionReportsExport    = "reports:export"
+)
+
+// PermissionCatalog describe cada permiso asignable
+var PermissionCatalog = map[string]string{
+	PermissionUsersManage:      "Gestionar usuarios e invitaciones",
+	PermissionRolesManage:      "Gestionar roles y sus permisos",
+	PermissionCategoriesWrite:  "Crear, editar y eliminar categorías",
+	PermissionLocationsWrite:   "Crear, editar y eliminar ubicaciones",
+	PermissionReasonCodesWrite: "Crear y editar motivos de movimiento",
+	PermissionProductsWrite:    "Crear, importar y eliminar productos y sus códigos de barras",
+	PermissionProductsUpdate:   "Editar productos",
+	PermissionProductsPrice:    "Cambiar el precio de venta de los productos",
+	PermissionSuppliersWrite:   "Crear, editar y eliminar proveedores",
+	PermissionPurchaseWrite:    "Crear y cancelar órdenes de compra",
+	PermissionPurchaseReceive:  "Recibir órdenes de compra",
+	PermissionSalesWrite:       "Crear y confirmar pedidos de clientes",
+	PermissionSalesFulfill:     "Despachar pedidos de clientes",
+	PermissionSalesCancel:      "Cancelar pedidos de clientes",
+	PermissionTransfersCreate:  "Crear transferencias",
+	PermissionTransfersReceive: "Recibir transferencias",
+	PermissionTransfersCancel:  "Cancelar transferencias",
+	PermissionCountsManage:     "Abrir, aprobar y cancelar conteos físicos",
+	PermissionCountsEnter:      "Cargar cantidades en conteos físicos",
+	PermissionMovementsCreate:  "Registrar movimientos",
+	PermissionMovementsReverse: "Revertir movimientos",
+	PermissionStockReconcile:   "Ver y corregir la reconciliación de stock",
+	PermissionStockSnapshots:   "Tomar, bloquear y eliminar fotos de stock",
+	PermissionReportsExport:    "Exportar listados y reportes",
+}
+
+// ValidPermission indica si el permiso existe en el catálogo ("*" queda reservado al rol admin)
+func ValidPermission(permission string) bool {
+	_, ok := PermissionCatalog[permission]
+	return ok
+}
+
+func joinPermissions(permissions ...string) string {
+	return strings.Join(permissions, ",")
+}
+
+// DefaultRoles son los roles iniciales. Solo se crean si no existen, así que los
+// cambios hechos desde la API se conservan.
+var DefaultRoles = []Role{
+	{Name: RoleAdmin, Description: "Administrador", Permissions: PermissionAll, System: true},
+	{Name: RoleEmployee, Description: "Empleado", System: true, Permissions: joinPermissions(
+		PermissionProductsUpdate, PermissionMovementsCreate, PermissionTransfersCreate, PermissionTransfersReceive,
+		PermissionCountsEnter, PermissionPurchaseReceive, PermissionSalesWrite, PermissionSalesFulfill,
+		PermissionReportsExport,
+	)},
+	{Name: "warehouse_clerk", Description: "Almacenero", Permissions: joinPermissions(
+		PermissionMovementsCreate, PermissionTransfersCreate, PermissionTransfersReceive,
+		PermissionCountsEnter, PermissionPurchaseReceive, PermissionSalesFulfill,
+	)},
+	{Name: "purchaser", Description: "Comprador", Permissions: joinPermissions(
+		PermissionSuppliersWrite, PermissionPurchaseWrite, PermissionPurchaseReceive, PermissionReportsExport,
+	)},
+	{Name: "auditor", Description: "Auditor (solo lectura)", Permissions: joinPermissions(
+		PermissionReportsExport,
+	)},
+	{Name: "store_manager", Description: "Encargado de tienda", Permissions: joinPermissions(
+		PermissionProductsUpdate, PermissionProductsPrice, PermissionMovementsCreate, PermissionMovementsReverse,
+		PermissionTransfersCreate, PermissionTransfersReceive, PermissionTransfersCancel,
+		PermissionCountsManage, PermissionCountsEnter, PermissionPurchaseReceive,
+		PermissionSalesWrite, PermissionSalesFulfill, PermissionSalesCancel, PermissionReportsExport,
+	)},
+}
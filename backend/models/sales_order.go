@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Estados de un pedido de cliente
+const (
+	SalesOrderStatusBorrador   = "borrador"
+	SalesOrderStatusConfirmada = "confirmada" // Reserva el stock pendiente de despachar
+	SalesOrderStatusParcial    = "parcial"    // Despachado en parte; reserva lo pendiente
+	SalesOrderStatusCompletada = "completada"
+	SalesOrderStatusCancelada  = "cancelada"
+)
+
+// ReservingSalesOrderStatuses son los estados cuyo pendiente queda reservado
+var ReservingSalesOrderStatuses = []string{SalesOrderStatusConfirmada, SalesOrderStatusParcial}
+
+// SalesOrder es un pedido de un cliente. Al confirmarlo se reserva el stock en la
+// ubicación de despacho; al despacharlo la reserva se convierte en movimientos de salida.
+type SalesOrder struct {
+	ID          uint             `gorm:"primaryKey" json:"id"`
+	Customer    string           `gorm:"not null" json:"customer"`
+	Reference   string           `json:"reference"`
+	LocationID  uint             `gorm:"not null;index" json:"location_id"` // Ubicación de despacho
+	Location    *Location        `gorm:"foreignKey:LocationID" json:"location,omitempty"`
+	Status      string           `gorm:"type:enum('borrador','confirmada','parcial','completada','cancelada');default:'borrador';index" json:"status"`
+	ShipDate    *time.Time       `json:"ship_date"`
+	Notes       string           `json:"notes"`
+	CreatedByID uint             `gorm:"not null" json:"created_by_id"`
+	ConfirmedAt *time.Time       `json:"confirmed_at,omitempty"`
+	Lines       []SalesOrderLine `gorm:"foreignKey:SalesOrderID" json:"lines,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+// SalesOrderLine es un producto pedido dentro de un pedido de cliente
+type SalesOrderLine struct {
+	ID                uint     `gorm:"primaryKey" json:"id"`
+	SalesOrderID      uint     `gorm:"not null;index" json:"sales_order_id"`
+	ProductID         uint     `gorm:"not null;index" json:"product_id"`
+	Product           *Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Quantity          int      `gorm:"not null" json:"quantity"`
+	FulfilledQuantity int      `gorm:"default:0" json:"fulfilled_quantity"`
+	UnitPrice         float64  `gorm:"type:decimal(12,2);default:0" json:"unit_price"`
+}
+
+// Pending devuelve la cantidad que falta despachar
+func (l SalesOrderLine) Pending() int {
+	if l.FulfilledQuantity >= l.Quantity {
+		return 0
+	}
+	return l.Quantity - l.FulfilledQuantity
+}
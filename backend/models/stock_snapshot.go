@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Tipos de foto de stock
+const (
+	SnapshotTypeMensual = "mensual" // Cierre de mes; se puede bloquear
+	SnapshotTypeManual  = "manual"
+)
+
+// StockSnapshot es una foto del stock y su valor a una fecha, calculada desde los movimientos.
+// Las consultas históricas parten de la última foto anterior a la fecha pedida y solo suman
+// los movimientos posteriores. Una foto bloqueada cierra el período: no se aceptan
+// movimientos con fecha igual o anterior.
+type StockSnapshot struct {
+	ID           uint                `gorm:"primaryKey" json:"id"`
+	SnapshotDate time.Time           `gorm:"not null;uniqueIndex" json:"snapshot_date"`
+	Type         string              `gorm:"type:enum('mensual','manual');default:'manual'" json:"type"`
+	Locked       bool                `gorm:"default:false;index" json:"locked"`
+	LockedAt     *time.Time          `json:"locked_at,omitempty"`
+	LockedByID   *uint               `json:"locked_by_id,omitempty"`
+	CreatedByID  *uint               `json:"created_by_id,omitempty"`
+	CreatedAt    time.Time           `json:"created_at"`
+	Lines        []StockSnapshotLine `gorm:"foreignKey:SnapshotID" json:"lines,omitempty"`
+}
+
+// StockSnapshotLine es la cantidad y el valor de un producto en una ubicación dentro de una foto.
+// LocationID es nulo para los movimientos anteriores a las ubicaciones.
+type StockSnapshotLine struct {
+	ID         uint     `gorm:"primaryKey" json:"id"`
+	SnapshotID uint     `gorm:"not null;index" json:"snapshot_id"`
+	ProductID  uint     `gorm:"not null;index" json:"product_id"`
+	Product    *Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	LocationID *uint    `json:"location_id"`
+	Quantity   int      `gorm:"not null" json:"quantity"`
+	Value      float64  `gorm:"type:decimal(16,4);default:0" json:"value"`      // A costo promedio
+	FifoValue  float64  `gorm:"type:decimal(16,4);default:0" json:"fifo_value"` // Según capas FIFO
+}
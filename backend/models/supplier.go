@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Supplier es un proveedor al que se le hacen órdenes de compra
+type Supplier struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Name        string    `gorm:"not null" json:"name"`
+	TaxID       *string   `gorm:"size:32;uniqueIndex" json:"tax_id"` // CUIT/RUC/NIF
+	ContactName string    `json:"contact_name"`
+	Email       string    `json:"email"`
+	Phone       string    `json:"phone"`
+	Address     string    `json:"address"`
+	Active      bool      `gorm:"default:true" json:"active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
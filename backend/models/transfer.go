@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Estados de una transferencia
+const (
+	TransferStatusEnTransito = "en_transito"
+	TransferStatusRecibida   = "recibida"
+	TransferStatusCancelada  = "cancelada"
+)
+
+// Transfer mueve stock de un producto entre dos ubicaciones.
+// La salida del origen se registra al despachar y la entrada al destino al recibir;
+// mientras está en tránsito las unidades no cuentan en el stock de ninguna ubicación.
+type Transfer struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	ProductID      uint       `gorm:"not null;index" json:"product_id"`
+	Product        Product    `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	FromLocationID uint       `gorm:"not null" json:"from_location_id"`
+	FromLocation   *Location  `gorm:"foreignKey:FromLocationID" json:"from_location,omitempty"`
+	ToLocationID   uint       `gorm:"not null" json:"to_location_id"`
+	ToLocation     *Location  `gorm:"foreignKey:ToLocationID" json:"to_location,omitempty"`
+	Quantity       int        `gorm:"not null" json:"quantity"`
+	Status         string     `gorm:"type:enum('en_transito','recibida','cancelada');default:'en_transito';index" json:"status"`
+	Description    string     `json:"description"`
+	UserID         uint       `gorm:"not null" json:"user_id"`
+	User           User       `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	ReceivedByID   *uint      `json:"received_by_id,omitempty"`
+	DispatchedAt   time.Time  `json:"dispatched_at"`
+	ReceivedAt     *time.Time `json:"received_at,omitempty"`
+	Movements      []Movement `gorm:"foreignKey:TransferID" json:"movements,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
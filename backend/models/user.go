@@ -11,8 +11,9 @@ type User struct {
 	Username  string         `gorm:"unique;not null" json:"username"`
 	Email     string         `gorm:"unique;not null" json:"email"`
 	Password  string         `gorm:"not null" json:"-"`
-	Role      string         `gorm:"type:enum('admin','employee');default:'employee'" json:"role"`
+	Role      string         `gorm:"size:50;not null;default:'employee';index" json:"role"` // Name de un Role
+	Active    bool           `gorm:"default:true" json:"active"`                            // Una cuenta desactivada no puede iniciar sesión
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"` // Los eliminados siguen visibles en el historial
 }
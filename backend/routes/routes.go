@@ -3,6 +3,7 @@ package routes
 import (
 	"github.com/Stormdead/inventory-control-panel/backend/controllers"
 	"github.com/Stormdead/inventory-control-panel/backend/middleware"
+	"github.com/Stormdead/inventory-control-panel/backend/models"
 	"github.com/gin-gonic/gin"
 )
 
@@ -13,8 +14,46 @@ func SetupRoutes(router *gin.Engine) {
 		auth := api.Group("/auth")
 		{
 			auth.POST("/register", controllers.Register)
+			auth.POST("/bootstrap", controllers.Bootstrap)
 			auth.POST("/login", controllers.Login)
+			auth.POST("/refresh", controllers.RefreshSession)
+			auth.POST("/logout", middleware.AuthMiddleware(), controllers.Logout)
 			auth.GET("/profile", middleware.AuthMiddleware(), controllers.GetProfile)
+			auth.GET("/invitations/:token", controllers.GetInvitationByToken)
+			auth.POST("/invitations/accept", controllers.AcceptInvitation)
+		}
+
+		// Rutas de gestión de usuarios e invitaciones (permiso users:manage)
+		users := api.Group("/users")
+		users.Use(middleware.AuthMiddleware(), middleware.RequirePermission(models.PermissionUsersManage))
+		{
+			users.GET("", controllers.GetUsers)
+			users.GET("/:id", controllers.GetUser)
+			users.POST("", controllers.CreateUser)
+			users.PUT("/:id", controllers.UpdateUser)
+			users.POST("/:id/password", controllers.ResetUserPassword)
+			users.DELETE("/:id", controllers.DeleteUser)
+		}
+
+		// Rutas de roles y permisos
+		roles := api.Group("/roles")
+		roles.Use(middleware.AuthMiddleware(), middleware.RequirePermission(models.PermissionRolesManage))
+		{
+			roles.GET("", controllers.GetRoles)
+			roles.GET("/permissions", controllers.GetPermissions)
+			roles.GET("/:id", controllers.GetRole)
+			roles.POST("", controllers.CreateRole)
+			roles.PUT("/:id", controllers.UpdateRole)
+			roles.DELETE("/:id", controllers.DeleteRole)
+		}
+
+		// Rutas de invitaciones
+		invitations := api.Group("/invitations")
+		invitations.Use(middleware.AuthMiddleware(), middleware.RequirePermission(models.PermissionUsersManage))
+		{
+			invitations.GET("", controllers.GetInvitations)
+			invitations.POST("", controllers.CreateInvitation)
+			invitations.DELETE("/:id", controllers.RevokeInvitation)
 		}
 
 		// Rutas de categorías
@@ -23,9 +62,53 @@ func SetupRoutes(router *gin.Engine) {
 		{
 			categories.GET("", controllers.GetCategories)
 			categories.GET("/:id", controllers.GetCategory)
-			categories.POST("", middleware.AdminMiddleware(), controllers.CreateCategory)
-			categories.PUT("/:id", middleware.AdminMiddleware(), controllers.UpdateCategory)
-			categories.DELETE("/:id", middleware.AdminMiddleware(), controllers.DeleteCategory)
+			categories.POST("", middleware.RequirePermission(models.PermissionCategoriesWrite), controllers.CreateCategory)
+			categories.PUT("/:id", middleware.RequirePermission(models.PermissionCategoriesWrite), controllers.UpdateCategory)
+			categories.DELETE("/:id", middleware.RequirePermission(models.PermissionCategoriesWrite), controllers.DeleteCategory)
+		}
+
+		// Rutas de ubicaciones (almacenes y tiendas)
+		locations := api.Group("/locations")
+		locations.Use(middleware.AuthMiddleware())
+		{
+			locations.GET("", controllers.GetLocations)
+			locations.GET("/:id", controllers.GetLocation)
+			locations.GET("/:id/stock", controllers.GetLocationStock)
+			locations.POST("", middleware.RequirePermission(models.PermissionLocationsWrite), controllers.CreateLocation)
+			locations.PUT("/:id", middleware.RequirePermission(models.PermissionLocationsWrite), controllers.UpdateLocation)
+			locations.DELETE("/:id", middleware.RequirePermission(models.PermissionLocationsWrite), controllers.DeleteLocation)
+		}
+
+		// Rutas del catálogo de motivos de movimiento
+		reasonCodes := api.Group("/reason-codes")
+		reasonCodes.Use(middleware.AuthMiddleware())
+		{
+			reasonCodes.GET("", controllers.GetReasonCodes)
+			reasonCodes.POST("", middleware.RequirePermission(models.PermissionReasonCodesWrite), controllers.CreateReasonCode)
+			reasonCodes.PUT("/:id", middleware.RequirePermission(models.PermissionReasonCodesWrite), controllers.UpdateReasonCode)
+		}
+
+		// Rutas de transferencias entre ubicaciones
+		transfers := api.Group("/transfers")
+		transfers.Use(middleware.AuthMiddleware())
+		{
+			transfers.GET("", controllers.GetTransfers)
+			transfers.GET("/:id", controllers.GetTransfer)
+			transfers.POST("", middleware.RequirePermission(models.PermissionTransfersCreate), middleware.Idempotency(), controllers.CreateTransfer)
+			transfers.POST("/:id/receive", middleware.RequirePermission(models.PermissionTransfersReceive), controllers.ReceiveTransfer)
+			transfers.POST("/:id/cancel", middleware.RequirePermission(models.PermissionTransfersCancel), controllers.CancelTransfer)
+		}
+
+		// Rutas de conteos físicos (cycle counts)
+		counts := api.Group("/counts")
+		counts.Use(middleware.AuthMiddleware())
+		{
+			counts.GET("", controllers.GetCountSessions)
+			counts.GET("/:id", controllers.GetCountSession)
+			counts.POST("", middleware.RequirePermission(models.PermissionCountsManage), controllers.OpenCountSession)
+			counts.POST("/:id/entries", middleware.RequirePermission(models.PermissionCountsEnter), controllers.SubmitCountEntries)
+			counts.POST("/:id/approve", middleware.RequirePermission(models.PermissionCountsManage), controllers.ApproveCountSession)
+			counts.POST("/:id/cancel", middleware.RequirePermission(models.PermissionCountsManage), controllers.CancelCountSession)
 		}
 
 		// Rutas de dashboard (requieren autenticación)
@@ -37,6 +120,14 @@ func SetupRoutes(router *gin.Engine) {
 			dashboard.GET("/low-stock-alerts", controllers.GetLowStockAlerts)
 			dashboard.GET("/movement-summary", controllers.GetMovementSummary)
 			dashboard.GET("/top-products", controllers.GetTopProducts)
+			dashboard.GET("/valuation", controllers.GetInventoryValuation)
+			dashboard.GET("/forecast", controllers.GetDemandForecast)
+			dashboard.GET("/analytics/abc", controllers.GetABCAnalysis)
+			dashboard.GET("/analytics/xyz", controllers.GetXYZAnalysis)
+			dashboard.GET("/analytics/turnover", controllers.GetInventoryTurnover)
+			dashboard.GET("/analytics/dead-stock", controllers.GetDeadStock)
+			dashboard.GET("/analytics/aging", controllers.GetStockAging)
+			dashboard.GET("/export/:report", middleware.RequirePermission(models.PermissionReportsExport), controllers.ExportDashboardReport)
 		}
 
 		// Rutas de productos
@@ -45,23 +136,79 @@ func SetupRoutes(router *gin.Engine) {
 		{
 			products.GET("", controllers.GetProducts)
 			products.GET("/low-stock", controllers.GetLowStockProducts)
+			products.GET("/replenishment", controllers.GetReplenishmentSuggestions)
+			products.GET("/lookup", controllers.LookupProduct)
+			products.GET("/export", middleware.RequirePermission(models.PermissionReportsExport), controllers.ExportProducts)
 			products.GET("/category/:category_id", controllers.GetProductsByCategory)
 			products.GET("/:id", controllers.GetProduct)
-			products.POST("", middleware.AdminMiddleware(), controllers.CreateProduct)
-			products.PUT("/:id", controllers.UpdateProduct)
-			products.DELETE("/:id", middleware.AdminMiddleware(), controllers.DeleteProduct)
+			products.POST("", middleware.RequirePermission(models.PermissionProductsWrite), middleware.Idempotency(), controllers.CreateProduct)
+			products.POST("/import", middleware.RequirePermission(models.PermissionProductsWrite), controllers.ImportProducts)
+			products.PUT("/:id", middleware.RequirePermission(models.PermissionProductsUpdate), controllers.UpdateProduct)
+			products.PATCH("/:id", middleware.RequirePermission(models.PermissionProductsUpdate), controllers.UpdateProduct)
+			products.DELETE("/:id", middleware.RequirePermission(models.PermissionProductsWrite), controllers.DeleteProduct)
+			products.POST("/:id/barcodes", middleware.RequirePermission(models.PermissionProductsWrite), controllers.AddProductBarcode)
+			products.DELETE("/:id/barcodes/:barcode_id", middleware.RequirePermission(models.PermissionProductsWrite), controllers.DeleteProductBarcode)
+		}
+
+		// Rutas de proveedores
+		suppliers := api.Group("/suppliers")
+		suppliers.Use(middleware.AuthMiddleware())
+		{
+			suppliers.GET("", controllers.GetSuppliers)
+			suppliers.GET("/:id", controllers.GetSupplier)
+			suppliers.POST("", middleware.RequirePermission(models.PermissionSuppliersWrite), controllers.CreateSupplier)
+			suppliers.PUT("/:id", middleware.RequirePermission(models.PermissionSuppliersWrite), controllers.UpdateSupplier)
+			suppliers.DELETE("/:id", middleware.RequirePermission(models.PermissionSuppliersWrite), controllers.DeleteSupplier)
+		}
+
+		// Rutas de órdenes de compra
+		purchaseOrders := api.Group("/purchase-orders")
+		purchaseOrders.Use(middleware.AuthMiddleware())
+		{
+			purchaseOrders.GET("", controllers.GetPurchaseOrders)
+			purchaseOrders.GET("/:id", controllers.GetPurchaseOrder)
+			purchaseOrders.POST("", middleware.RequirePermission(models.PermissionPurchaseWrite), controllers.CreatePurchaseOrder)
+			purchaseOrders.POST("/:id/receive", middleware.RequirePermission(models.PermissionPurchaseReceive), middleware.Idempotency(), controllers.ReceivePurchaseOrder)
+			purchaseOrders.POST("/:id/cancel", middleware.RequirePermission(models.PermissionPurchaseWrite), controllers.CancelPurchaseOrder)
+		}
+
+		// Rutas de pedidos de clientes
+		salesOrders := api.Group("/sales-orders")
+		salesOrders.Use(middleware.AuthMiddleware())
+		{
+			salesOrders.GET("", controllers.GetSalesOrders)
+			salesOrders.GET("/:id", controllers.GetSalesOrder)
+			salesOrders.POST("", middleware.RequirePermission(models.PermissionSalesWrite), controllers.CreateSalesOrder)
+			salesOrders.POST("/:id/confirm", middleware.RequirePermission(models.PermissionSalesWrite), controllers.ConfirmSalesOrder)
+			salesOrders.POST("/:id/fulfill", middleware.RequirePermission(models.PermissionSalesFulfill), middleware.Idempotency(), controllers.FulfillSalesOrder)
+			salesOrders.POST("/:id/cancel", middleware.RequirePermission(models.PermissionSalesCancel), controllers.CancelSalesOrder)
+		}
+
+		// Rutas de control de stock
+		stock := api.Group("/stock")
+		stock.Use(middleware.AuthMiddleware())
+		{
+			stock.GET("/as-of", controllers.GetStockAsOf)
+			stock.GET("/reconciliation", middleware.RequirePermission(models.PermissionStockReconcile), controllers.GetStockReconciliation)
+			stock.POST("/reconciliation", middleware.RequirePermission(models.PermissionStockReconcile), controllers.FixStockReconciliation)
+			stock.GET("/snapshots", controllers.GetStockSnapshots)
+			stock.GET("/snapshots/:id", controllers.GetStockSnapshot)
+			stock.POST("/snapshots", middleware.RequirePermission(models.PermissionStockSnapshots), controllers.TakeStockSnapshot)
+			stock.POST("/snapshots/:id/lock", middleware.RequirePermission(models.PermissionStockSnapshots), controllers.LockStockSnapshot)
+			stock.DELETE("/snapshots/:id", middleware.RequirePermission(models.PermissionStockSnapshots), controllers.DeleteStockSnapshot)
 		}
 
 		// Rutas de movimientos
 		movements := api.Group("/movements")
 		movements.Use(middleware.AuthMiddleware())
 		{
-			movements.GET("", controllers.GetMovements)                                        // Listar todos
-			movements.GET("/type/:type", controllers.GetMovementsByType)                       // Por tipo
-			movements.GET("/product/:product_id", controllers.GetMovementsByProduct)           // Por producto
-			movements.GET("/:id", controllers.GetMovement)                                     // Obtener uno
-			movements.POST("", controllers.CreateMovement)                                     // Crear movimiento
-			movements.DELETE("/:id", middleware.AdminMiddleware(), controllers.DeleteMovement) // Eliminar (admin)
+			movements.GET("", controllers.GetMovements)                                                                                              // Listar todos
+			movements.GET("/type/:type", controllers.GetMovementsByType)                                                                             // Por tipo
+			movements.GET("/product/:product_id", controllers.GetMovementsByProduct)                                                                 // Por producto
+			movements.GET("/export", middleware.RequirePermission(models.PermissionReportsExport), controllers.ExportMovements)                      // Exportar CSV/XLSX
+			movements.GET("/:id", controllers.GetMovement)                                                                                           // Obtener uno
+			movements.POST("", middleware.RequirePermission(models.PermissionMovementsCreate), middleware.Idempotency(), controllers.CreateMovement) // Crear movimiento
+			movements.POST("/:id/reverse", middleware.RequirePermission(models.PermissionMovementsReverse), controllers.ReverseMovement)             // Revertir
 		}
 	}
 }
@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInventoryAnalytics(t *testing.T) {
+	if testToken == "" {
+		t.Skip("No hay token disponible. Ejecuta TestLogin primero")
+	}
+
+	w := MakeRequest("POST", "/api/categories", map[string]interface{}{"name": "Analítica"}, testToken)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var response map[string]interface{}
+	ParseResponse(w, &response)
+	categoryID := response["category"].(map[string]interface{})["id"]
+
+	expensiveID := createMovementTestProduct(t, "Producto Caro", 100, map[string]interface{}{"category_id": categoryID, "average_cost": 10})
+	cheapID := createMovementTestProduct(t, "Producto Barato", 100, map[string]interface{}{"category_id": categoryID, "average_cost": 1})
+
+	for productID, quantity := range map[uint]int{expensiveID: 9, cheapID: 10} {
+		w := MakeRequest("POST", "/api/movements", map[string]interface{}{
+			"product_id": productID,
+			"type":       "salida",
+			"quantity":   quantity,
+		}, testToken)
+		assert.Equal(t, http.StatusCreated, w.Code)
+	}
+
+	lineFor := func(response map[string]interface{}, productID uint) map[string]interface{} {
+		for _, item := range response["products"].([]interface{}) {
+			line := item.(map[string]interface{})
+			if uint(line["product_id"].(float64)) == productID {
+				return line
+			}
+		}
+		return nil
+	}
+
+	t.Run("Clasificación ABC por valor consumido", func(t *testing.T) {
+		w := MakeRequest("GET", fmt.Sprintf("/api/dashboard/analytics/abc?category_id=%v", categoryID), nil, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		assert.Equal(t, "A", lineFor(response, expensiveID)["class"])
+		assert.Equal(t, "B", lineFor(response, cheapID)["class"])
+		assert.Equal(t, float64(90), lineFor(response, expensiveID)["value"])
+	})
+
+	t.Run("Clasificación XYZ", func(t *testing.T) {
+		w := MakeRequest("GET", fmt.Sprintf("/api/dashboard/analytics/xyz?category_id=%v", categoryID), nil, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		// Un solo pico de consumo en el período es demanda muy variable
+		assert.Equal(t, "Z", lineFor(response, cheapID)["class"])
+	})
+
+	t.Run("Rotación y días de cobertura", func(t *testing.T) {
+		w := MakeRequest("GET", fmt.Sprintf("/api/dashboard/analytics/turnover?category_id=%v", categoryID), nil, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		line := lineFor(response, expensiveID)
+		assert.Equal(t, float64(91), line["closing_stock"])
+		assert.Equal(t, float64(90), line["cogs"])
+		assert.InDelta(t, 0.2, line["turnover"], 0.01)
+	})
+
+	t.Run("Stock inmovilizado", func(t *testing.T) {
+		from := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+		to := time.Now().AddDate(0, 0, 2).Format("2006-01-02")
+		w := MakeRequest("GET", fmt.Sprintf("/api/dashboard/analytics/dead-stock?category_id=%v&from=%s&to=%s", categoryID, from, to), nil, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		assert.NotNil(t, lineFor(response, cheapID))
+
+		w = MakeRequest("GET", fmt.Sprintf("/api/dashboard/analytics/dead-stock?category_id=%v", categoryID), nil, testToken)
+		ParseResponse(w, &response)
+		assert.Nil(t, lineFor(response, cheapID))
+	})
+
+	t.Run("Antigüedad del stock", func(t *testing.T) {
+		w := MakeRequest("GET", fmt.Sprintf("/api/dashboard/analytics/aging?category_id=%v", categoryID), nil, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		line := lineFor(response, expensiveID)
+		assert.Equal(t, float64(91), line["buckets"].(map[string]interface{})["0-30"])
+	})
+}
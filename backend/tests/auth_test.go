@@ -51,6 +51,19 @@ func TestRegister(t *testing.T) {
 				assert.Contains(t, resp, "error")
 			},
 		},
+		{
+			name: "No se puede autoasignar admin",
+			payload: map[string]interface{}{
+				"username": "intruso",
+				"email":    "intruso@example.com",
+				"password": "password123",
+				"role":     "admin",
+			},
+			expectedStatus: http.StatusForbidden,
+			checkResponse: func(t *testing.T, resp map[string]interface{}) {
+				assert.Contains(t, resp, "error")
+			},
+		},
 		{
 			name: "Contraseña muy corta",
 			payload: map[string]interface{}{
@@ -95,14 +108,22 @@ func TestRegister(t *testing.T) {
 func TestLogin(t *testing.T) {
 	CleanupDatabase()
 
-	// Primero registrar un usuario
-	registerPayload := map[string]interface{}{
+	// Primero crear el admin inicial (la base está vacía)
+	bootstrapPayload := map[string]interface{}{
 		"username": "logintest",
 		"email":    "login@example.com",
 		"password": "password123",
-		"role":     "admin",
 	}
-	MakeRequest("POST", "/api/auth/register", registerPayload, "")
+	w := MakeRequest("POST", "/api/auth/bootstrap", bootstrapPayload, "")
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	// Con usuarios existentes el bootstrap ya no está disponible
+	w = MakeRequest("POST", "/api/auth/bootstrap", map[string]interface{}{
+		"username": "otroadmin",
+		"email":    "otro@example.com",
+		"password": "password123",
+	}, "")
+	assert.Equal(t, http.StatusConflict, w.Code)
 
 	testCases := []struct {
 		name           string
@@ -195,3 +216,76 @@ func TestGetProfile(t *testing.T) {
 		assert.Equal(t, http.StatusUnauthorized, w.Code)
 	})
 }
+
+func TestRefreshAndLogout(t *testing.T) {
+	if testToken == "" {
+		t.Skip("No hay token disponible. Ejecuta TestLogin primero")
+	}
+
+	// Sesión propia para no invalidar testToken
+	login := func() map[string]interface{} {
+		w := MakeRequest("POST", "/api/auth/login", map[string]interface{}{
+			"email":    "login@example.com",
+			"password": "password123",
+		}, "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		return response
+	}
+	refresh := func(token string) (int, map[string]interface{}) {
+		w := MakeRequest("POST", "/api/auth/refresh", map[string]interface{}{"refresh_token": token}, "")
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		return w.Code, response
+	}
+
+	t.Run("Rotación del refresh token", func(t *testing.T) {
+		session := login()
+		assert.Contains(t, session, "refresh_token")
+		assert.Contains(t, session, "expires_in")
+
+		code, rotated := refresh(session["refresh_token"].(string))
+		assert.Equal(t, http.StatusOK, code)
+		assert.NotEqual(t, session["refresh_token"], rotated["refresh_token"])
+
+		w := MakeRequest("GET", "/api/auth/profile", nil, rotated["token"].(string))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Reutilizar un refresh token revoca la familia", func(t *testing.T) {
+		session := login()
+		_, rotated := refresh(session["refresh_token"].(string))
+
+		code, _ := refresh(session["refresh_token"].(string))
+		assert.Equal(t, http.StatusUnauthorized, code)
+
+		// El token legítimo más reciente también quedó revocado
+		code, _ = refresh(rotated["refresh_token"].(string))
+		assert.Equal(t, http.StatusUnauthorized, code)
+		w := MakeRequest("GET", "/api/auth/profile", nil, rotated["token"].(string))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Logout revoca el token de acceso", func(t *testing.T) {
+		session := login()
+		token := session["token"].(string)
+
+		w := MakeRequest("POST", "/api/auth/logout", nil, token)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = MakeRequest("GET", "/api/auth/profile", nil, token)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		code, _ := refresh(session["refresh_token"].(string))
+		assert.Equal(t, http.StatusUnauthorized, code)
+
+		// Las demás sesiones siguen activas
+		w = MakeRequest("GET", "/api/auth/profile", nil, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Refresh token inválido", func(t *testing.T) {
+		code, _ := refresh("no-existe")
+		assert.Equal(t, http.StatusUnauthorized, code)
+	})
+}
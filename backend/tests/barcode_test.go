@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Stormdead/inventory-control-panel/backend/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateBarcode(t *testing.T) {
+	testCases := []struct {
+		name      string
+		code      string
+		symbology string
+		valid     bool
+	}{
+		{"EAN-13 válido", "4006381333931", utils.SymbologyEAN13, true},
+		{"EAN-13 con dígito de control incorrecto", "4006381333932", utils.SymbologyEAN13, false},
+		{"UPC-A válido", "036000291452", utils.SymbologyUPCA, true},
+		{"UPC-A con letras", "03600029145A", utils.SymbologyUPCA, false},
+		{"Code128 válido", "SKU-ABC-001", utils.SymbologyCode128, true},
+		{"Code128 con caracteres de control", "ABC\n001", utils.SymbologyCode128, false},
+		{"Simbología desconocida", "123", "qr", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := utils.ValidateBarcode(tc.code, tc.symbology)
+			assert.Equal(t, tc.valid, err == nil)
+		})
+	}
+
+	assert.Equal(t, utils.SymbologyEAN13, utils.DetectSymbology("4006381333931"))
+	assert.Equal(t, utils.SymbologyUPCA, utils.DetectSymbology("036000291452"))
+	assert.Equal(t, utils.SymbologyCode128, utils.DetectSymbology("SKU-ABC-001"))
+
+	assert.Equal(t, "0036000291452", utils.CanonicalBarcode("036000291452"))
+	assert.Equal(t, "4006381333931", utils.CanonicalBarcode("4006381333931"))
+	assert.Equal(t, []string{"0036000291452", "036000291452"}, utils.EquivalentCodes("0036000291452"))
+}
+
+func TestProductLookup(t *testing.T) {
+	if testToken == "" {
+		t.Skip("No hay token disponible. Ejecuta TestLogin primero")
+	}
+
+	w := MakeRequest("POST", "/api/products", map[string]interface{}{
+		"name":     "Producto Escaneable",
+		"price":    3.5,
+		"sku":      "ESC-001",
+		"barcodes": []map[string]interface{}{{"code": "4006381333931"}},
+	}, testToken)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	t.Run("Código de barras con dígito de control inválido", func(t *testing.T) {
+		w := MakeRequest("POST", "/api/products", map[string]interface{}{
+			"name":     "Producto Inválido",
+			"price":    3.5,
+			"barcodes": []map[string]interface{}{{"code": "4006381333932"}},
+		}, testToken)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Buscar por código de barras y por SKU", func(t *testing.T) {
+		for _, code := range []string{"4006381333931", "ESC-001"} {
+			w := MakeRequest("GET", "/api/products/lookup?code="+code, nil, testToken)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			var response map[string]interface{}
+			ParseResponse(w, &response)
+			assert.Equal(t, "Producto Escaneable", response["product"].(map[string]interface{})["name"])
+		}
+	})
+
+	t.Run("Movimiento con código de barras", func(t *testing.T) {
+		w := MakeRequest("POST", "/api/movements", map[string]interface{}{
+			"barcode":  "4006381333931",
+			"type":     "entrada",
+			"quantity": 2,
+		}, testToken)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("UPC-A se guarda como EAN-13 y se encuentra con ambas formas", func(t *testing.T) {
+		w := MakeRequest("POST", "/api/products", map[string]interface{}{
+			"name":     "Producto UPC",
+			"price":    2,
+			"barcodes": []map[string]interface{}{{"code": "036000291452"}},
+		}, testToken)
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		barcode := response["product"].(map[string]interface{})["barcodes"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "0036000291452", barcode["code"])
+		assert.Equal(t, utils.SymbologyEAN13, barcode["symbology"])
+
+		for _, code := range []string{"036000291452", "0036000291452"} {
+			w := MakeRequest("GET", "/api/products/lookup?code="+code, nil, testToken)
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			ParseResponse(w, &response)
+			assert.Equal(t, "Producto UPC", response["product"].(map[string]interface{})["name"])
+		}
+	})
+
+	t.Run("SKU y código de barras no se cruzan entre productos", func(t *testing.T) {
+		w := MakeRequest("POST", "/api/products", map[string]interface{}{
+			"name":  "Producto SKU Cruzado",
+			"price": 2,
+			"sku":   "4006381333931",
+		}, testToken)
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		w = MakeRequest("POST", "/api/products", map[string]interface{}{
+			"name":     "Producto Código Cruzado",
+			"price":    2,
+			"barcodes": []map[string]interface{}{{"code": "ESC-001"}},
+		}, testToken)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Código desconocido", func(t *testing.T) {
+		w := MakeRequest("GET", "/api/products/lookup?code=NOEXISTE", nil, testToken)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
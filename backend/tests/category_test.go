@@ -12,17 +12,7 @@ var testCategoryID uint
 
 func TestCreateCategory(t *testing.T) {
 	if testToken == "" {
-		// Crear usuario admin y obtener token
-		registerPayload := map[string]interface{}{
-			"username": "admin",
-			"email":    "admin@test.com",
-			"password": "admin123",
-			"role":     "admin",
-		}
-		w := MakeRequest("POST", "/api/auth/register", registerPayload, "")
-		var response map[string]interface{}
-		ParseResponse(w, &response)
-		testToken = response["token"].(string)
+		t.Skip("No hay token disponible. Ejecuta TestLogin primero")
 	}
 
 	testCases := []struct {
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountSession(t *testing.T) {
+	if testToken == "" {
+		t.Skip("No hay token disponible. Ejecuta TestLogin primero")
+	}
+
+	w := MakeRequest("POST", "/api/categories", map[string]interface{}{"name": "Categoría Conteo"}, testToken)
+	var catResp map[string]interface{}
+	ParseResponse(w, &catResp)
+	categoryID := catResp["category"].(map[string]interface{})["id"].(float64)
+
+	w = MakeRequest("POST", "/api/products", map[string]interface{}{
+		"name":        "Producto Conteo",
+		"price":       5.0,
+		"stock":       10,
+		"category_id": categoryID,
+	}, testToken)
+	var prodResp map[string]interface{}
+	ParseResponse(w, &prodResp)
+	productID := prodResp["product"].(map[string]interface{})["id"].(float64)
+
+	var countID uint
+
+	t.Run("Abrir conteo congela el stock esperado", func(t *testing.T) {
+		w := MakeRequest("POST", "/api/counts", map[string]interface{}{"category_id": categoryID}, testToken)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+
+		count := response["count"].(map[string]interface{})
+		countID = uint(count["id"].(float64))
+		lines := count["lines"].([]interface{})
+		assert.Len(t, lines, 1)
+		assert.Equal(t, float64(10), lines[0].(map[string]interface{})["expected_quantity"])
+	})
+
+	t.Run("Registrar cantidad contada", func(t *testing.T) {
+		w := MakeRequest("POST", fmt.Sprintf("/api/counts/%d/entries", countID), map[string]interface{}{
+			"items": []map[string]interface{}{{"product_id": productID, "counted_quantity": 7}},
+		}, testToken)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+
+		line := response["count"].(map[string]interface{})["lines"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, float64(-3), line["variance"])
+	})
+
+	t.Run("Aprobar conserva los movimientos hechos durante el conteo", func(t *testing.T) {
+		// Salida registrada mientras el conteo estaba abierto
+		MakeRequest("POST", "/api/movements", map[string]interface{}{
+			"product_id": productID,
+			"type":       "salida",
+			"quantity":   2,
+		}, testToken)
+
+		w := MakeRequest("POST", fmt.Sprintf("/api/counts/%d/approve", countID), nil, testToken)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = MakeRequest("GET", fmt.Sprintf("/api/products/%d", uint(productID)), nil, testToken)
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		assert.Equal(t, float64(5), response["product"].(map[string]interface{})["stock"])
+	})
+
+	t.Run("No se puede aprobar dos veces", func(t *testing.T) {
+		w := MakeRequest("POST", fmt.Sprintf("/api/counts/%d/approve", countID), nil, testToken)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
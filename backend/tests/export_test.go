@@ -0,0 +1,62 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Stormdead/inventory-control-panel/backend/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExports(t *testing.T) {
+	if testToken == "" {
+		t.Skip("No hay token disponible. Ejecuta TestLogin primero")
+	}
+
+	productID := createMovementTestProduct(t, "Producto Exportación", 15)
+
+	t.Run("Productos en CSV con filtros del listado", func(t *testing.T) {
+		w := MakeRequest("GET", "/api/products/export?search=Exportaci%C3%B3n", nil, testToken)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Disposition"), ".csv")
+
+		rows, err := utils.ReadCSV(w.Body.Bytes())
+		assert.NoError(t, err)
+		assert.Len(t, rows, 2)
+		assert.Equal(t, "nombre", rows[0][2])
+		assert.Equal(t, "Producto Exportación", rows[1][2])
+		assert.Equal(t, "15", rows[1][6])
+	})
+
+	t.Run("Movimientos en XLSX con usuario y producto", func(t *testing.T) {
+		w := MakeRequest("GET", fmt.Sprintf("/api/movements/export?format=xlsx&product_id=%d", productID), nil, testToken)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		rows, err := utils.ReadXLSX(w.Body.Bytes())
+		assert.NoError(t, err)
+		assert.Len(t, rows, 2)
+		assert.Equal(t, "ajuste_entrada", rows[1][2])
+		assert.Equal(t, "Producto Exportación", rows[1][5])
+		assert.NotEmpty(t, rows[1][10])
+	})
+
+	t.Run("Reporte del dashboard", func(t *testing.T) {
+		w := MakeRequest("GET", "/api/dashboard/export/stats", nil, testToken)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, strings.Contains(w.Body.String(), "total_productos"))
+
+		w = MakeRequest("GET", "/api/dashboard/export/desconocido", nil, testToken)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Formato inválido", func(t *testing.T) {
+		w := MakeRequest("GET", "/api/products/export?format=pdf", nil, testToken)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
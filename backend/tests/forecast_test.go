@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Stormdead/inventory-control-panel/backend/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForecastDemand(t *testing.T) {
+	t.Run("Promedio móvil", func(t *testing.T) {
+		result, err := utils.ForecastDemand([]float64{100, 2, 4, 6}, 3, utils.ForecastOptions{
+			Method: utils.ForecastMovingAverage, Window: 3,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []float64{4, 4, 4}, result.Values)
+		assert.False(t, result.Seasonal)
+	})
+
+	t.Run("Suavizado exponencial", func(t *testing.T) {
+		result, err := utils.ForecastDemand([]float64{10, 20}, 1, utils.ForecastOptions{
+			Method: utils.ForecastExponential, Alpha: 0.5,
+		})
+		assert.NoError(t, err)
+		assert.InDelta(t, 15, result.Values[0], 0.0001)
+	})
+
+	t.Run("Estacionalidad con dos ciclos completos", func(t *testing.T) {
+		history := []float64{10, 30, 10, 30}
+		result, err := utils.ForecastDemand(history, 2, utils.ForecastOptions{
+			Method: utils.ForecastMovingAverage, Window: 4, SeasonLength: 2,
+		})
+		assert.NoError(t, err)
+		assert.True(t, result.Seasonal)
+		assert.InDelta(t, 10, result.Values[0], 0.0001)
+		assert.InDelta(t, 30, result.Values[1], 0.0001)
+	})
+
+	t.Run("Sin historia suficiente no hay estacionalidad", func(t *testing.T) {
+		result, err := utils.ForecastDemand([]float64{10, 30, 10}, 1, utils.ForecastOptions{
+			Method: utils.ForecastMovingAverage, Window: 3, SeasonLength: 2,
+		})
+		assert.NoError(t, err)
+		assert.False(t, result.Seasonal)
+	})
+
+	t.Run("Método inválido", func(t *testing.T) {
+		_, err := utils.ForecastDemand([]float64{1}, 1, utils.ForecastOptions{Method: "arima"})
+		assert.Error(t, err)
+	})
+}
+
+func TestDemandForecastEndpoint(t *testing.T) {
+	if testToken == "" {
+		t.Skip("No hay token disponible. Ejecuta TestLogin primero")
+	}
+
+	productID := createMovementTestProduct(t, "Producto Pronóstico", 20)
+	w := MakeRequest("POST", "/api/movements", map[string]interface{}{
+		"product_id": productID,
+		"type":       "salida",
+		"quantity":   10,
+	}, testToken)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = MakeRequest("GET", fmt.Sprintf("/api/dashboard/forecast?product_id=%d&weeks=4", productID), nil, testToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	ParseResponse(w, &response)
+	products := response["products"].([]interface{})
+	if assert.Len(t, products, 1) {
+		forecast := products[0].(map[string]interface{})
+		assert.Equal(t, float64(10), forecast["average_weekly"])
+		assert.Len(t, forecast["forecast"], 4)
+		assert.NotNil(t, forecast["stockout_date"])
+		assert.Equal(t, true, forecast["stockout_in_horizon"])
+	}
+
+	w = MakeRequest("GET", "/api/dashboard/forecast?method=arima", nil, testToken)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
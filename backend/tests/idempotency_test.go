@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Stormdead/inventory-control-panel/backend/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotentMovement(t *testing.T) {
+	if testToken == "" {
+		t.Skip("No hay token disponible. Ejecuta TestLogin primero")
+	}
+
+	productID := createMovementTestProduct(t, "Producto Idempotencia", 0)
+	headers := map[string]string{"Idempotency-Key": fmt.Sprintf("scanner-%d", productID)}
+	payload := map[string]interface{}{
+		"product_id": productID,
+		"type":       "entrada",
+		"quantity":   4,
+	}
+
+	first := MakeRequestWithHeaders("POST", "/api/movements", payload, testToken, headers)
+	assert.Equal(t, http.StatusCreated, first.Code)
+
+	t.Run("Reintento devuelve la respuesta original", func(t *testing.T) {
+		retry := MakeRequestWithHeaders("POST", "/api/movements", payload, testToken, headers)
+
+		assert.Equal(t, http.StatusCreated, retry.Code)
+		assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+		assert.JSONEq(t, first.Body.String(), retry.Body.String())
+
+		w := MakeRequest("GET", fmt.Sprintf("/api/products/%d", productID), nil, testToken)
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		assert.Equal(t, float64(4), response["product"].(map[string]interface{})["stock"])
+	})
+
+	t.Run("Misma clave con otro cuerpo", func(t *testing.T) {
+		other := map[string]interface{}{
+			"product_id": productID,
+			"type":       "entrada",
+			"quantity":   9,
+		}
+		w := MakeRequestWithHeaders("POST", "/api/movements", other, testToken, headers)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+}
+
+func TestIdempotencyScopedByOrder(t *testing.T) {
+	if testToken == "" {
+		t.Skip("No hay token disponible. Ejecuta TestLogin primero")
+	}
+
+	productID := createMovementTestProduct(t, "Producto Idempotencia Pedidos", 10)
+
+	createConfirmedOrder := func() uint {
+		w := MakeRequest("POST", "/api/sales-orders", map[string]interface{}{
+			"customer": "Cliente Idempotencia",
+			"lines": []map[string]interface{}{
+				{"product_id": productID, "quantity": 3, "unit_price": 20},
+			},
+		}, testToken)
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		orderID := uint(response["sales_order"].(map[string]interface{})["id"].(float64))
+
+		w = MakeRequest("POST", fmt.Sprintf("/api/sales-orders/%d/confirm", orderID), nil, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+		return orderID
+	}
+
+	// La misma clave y el mismo cuerpo en dos pedidos distintos despachan ambos
+	headers := map[string]string{"Idempotency-Key": fmt.Sprintf("fulfill-%d", productID)}
+	for _, orderID := range []uint{createConfirmedOrder(), createConfirmedOrder()} {
+		w := MakeRequestWithHeaders("POST", fmt.Sprintf("/api/sales-orders/%d/fulfill", orderID),
+			map[string]interface{}{}, testToken, headers)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		order := response["sales_order"].(map[string]interface{})
+		assert.Equal(t, float64(orderID), order["id"])
+	}
+
+	w := MakeRequest("GET", fmt.Sprintf("/api/products/%d", productID), nil, testToken)
+	var response map[string]interface{}
+	ParseResponse(w, &response)
+	assert.Equal(t, float64(4), response["product"].(map[string]interface{})["stock"])
+}
+
+func TestIdempotencyReleasedOnPanic(t *testing.T) {
+	if testToken == "" {
+		t.Skip("No hay token disponible. Ejecuta TestLogin primero")
+	}
+
+	// Un handler que falla con panic la primera vez y responde bien después
+	calls := 0
+	engine := gin.New()
+	engine.Use(gin.Recovery())
+	engine.POST("/idempotency-panic", middleware.Idempotency(), func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			panic("fallo inesperado")
+		}
+		c.JSON(http.StatusCreated, gin.H{"calls": calls})
+	})
+
+	key := fmt.Sprintf("panic-%d", time.Now().UnixNano())
+	send := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/idempotency-panic", strings.NewReader(`{}`))
+		req.Header.Set("Idempotency-Key", key)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusInternalServerError, send().Code)
+
+	// El reintento con la misma clave vuelve a ejecutar el handler en lugar de quedar en 409
+	retry := send()
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, 2, calls)
+}
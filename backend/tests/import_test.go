@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportProducts(t *testing.T) {
+	if testToken == "" {
+		t.Skip("No hay token disponible. Ejecuta TestLogin primero")
+	}
+
+	csv := []byte("nombre,precio,stock,categoria,sku\n" +
+		"Tornillo 3mm,0.5,100,Ferretería Import,IMP-001\n" +
+		"Sin precio,,5,Ferretería Import,IMP-002\n" +
+		"Tuerca 3mm,0.3,abc,Ferretería Import,IMP-003\n")
+
+	t.Run("Dry-run reporta errores por fila", func(t *testing.T) {
+		w := MakeUploadRequest("/api/products/import", "catalogo.csv", csv,
+			map[string]string{"dry_run": "true", "create_categories": "true"}, testToken)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+
+		assert.Equal(t, float64(1), response["valid_rows"])
+		rowErrors := response["errors"].([]interface{})
+		assert.Len(t, rowErrors, 2)
+		assert.Equal(t, float64(3), rowErrors[0].(map[string]interface{})["row"])
+		assert.Equal(t, []interface{}{"Ferretería Import"}, response["categories_to_create"])
+
+		// Nada se guardó
+		w = MakeRequest("GET", "/api/products/lookup?code=IMP-001", nil, testToken)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Importación con errores no guarda nada", func(t *testing.T) {
+		w := MakeUploadRequest("/api/products/import", "catalogo.csv", csv,
+			map[string]string{"create_categories": "true"}, testToken)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+
+	t.Run("Importación válida", func(t *testing.T) {
+		valid := []byte("nombre;precio;stock;categoria;sku\n" +
+			"Tornillo 3mm;0,5;100;Ferretería Import;IMP-001\n")
+
+		w := MakeUploadRequest("/api/products/import", "catalogo.csv", valid,
+			map[string]string{"create_categories": "true"}, testToken)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = MakeRequest("GET", "/api/products/lookup?code=IMP-001", nil, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+
+		product := response["product"].(map[string]interface{})
+		assert.Equal(t, float64(100), product["stock"])
+		assert.Equal(t, "Ferretería Import", product["category"].(map[string]interface{})["name"])
+	})
+
+	t.Run("Importación por bloques reutiliza las categorías creadas", func(t *testing.T) {
+		chunked := []byte("nombre,precio,stock,categoria,sku\n" +
+			"Arandela,0.1,10,Bloques Import,IMP-101\n" +
+			"Clavo,0.2,10,Bloques Import,IMP-102\n" +
+			"Taco,0.3,10,Bloques Import,IMP-103\n")
+
+		w := MakeUploadRequest("/api/products/import", "catalogo.csv", chunked,
+			map[string]string{"create_categories": "true", "chunk_size": "1"}, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		assert.Equal(t, float64(3), response["imported"])
+
+		var categoryIDs []interface{}
+		for _, code := range []string{"IMP-101", "IMP-102", "IMP-103"} {
+			w = MakeRequest("GET", "/api/products/lookup?code="+code, nil, testToken)
+			ParseResponse(w, &response)
+			categoryIDs = append(categoryIDs, response["product"].(map[string]interface{})["category_id"])
+		}
+		assert.Equal(t, categoryIDs[0], categoryIDs[1])
+		assert.Equal(t, categoryIDs[0], categoryIDs[2])
+	})
+
+	t.Run("XLSX con filas o columnas fuera de rango", func(t *testing.T) {
+		for _, sheetData := range []string{
+			`<row r="2000000000"><c r="A2000000000" t="inlineStr"><is><t>x</t></is></c></row>`,
+			`<row r="1"><c r="ZZZZ1" t="inlineStr"><is><t>x</t></is></c></row>`,
+		} {
+			xlsx := buildTestXLSX(t, sheetData)
+			w := MakeUploadRequest("/api/products/import", "catalogo.xlsx", xlsx, nil, testToken)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("Archivo demasiado grande", func(t *testing.T) {
+		big := []byte("nombre,precio\n" + strings.Repeat("a,1\n", 3<<20))
+
+		w := MakeUploadRequest("/api/products/import", "catalogo.csv", big, nil, testToken)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+}
+
+// buildTestXLSX arma un XLSX mínimo con una sola hoja
+func buildTestXLSX(t *testing.T, sheetData string) []byte {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	f, err := archive.Create("xl/worksheets/sheet1.xml")
+	assert.NoError(t, err)
+	_, err = f.Write([]byte(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+		sheetData + `</sheetData></worksheet>`))
+	assert.NoError(t, err)
+	assert.NoError(t, archive.Close())
+	return buf.Bytes()
+}
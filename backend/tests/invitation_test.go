@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvitations(t *testing.T) {
+	if testToken == "" {
+		t.Skip("No hay token disponible. Ejecuta TestLogin primero")
+	}
+
+	invite := func(email, role string) map[string]interface{} {
+		w := MakeRequest("POST", "/api/invitations", map[string]interface{}{
+			"email": email,
+			"role":  role,
+		}, testToken)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		return response
+	}
+
+	t.Run("Aceptar una invitación con rol admin", func(t *testing.T) {
+		token := invite("invitado@example.com", "admin")["token"].(string)
+
+		w := MakeRequest("GET", "/api/auth/invitations/"+token, nil, "")
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = MakeRequest("POST", "/api/auth/invitations/accept", map[string]interface{}{
+			"token":    token,
+			"username": "invitado",
+			"password": "password123",
+		}, "")
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		user := response["user"].(map[string]interface{})
+		assert.Equal(t, "admin", user["role"])
+		assert.Equal(t, "invitado@example.com", user["email"])
+
+		// El token es de un solo uso
+		w = MakeRequest("POST", "/api/auth/invitations/accept", map[string]interface{}{
+			"token":    token,
+			"username": "invitado2",
+			"password": "password123",
+		}, "")
+		assert.Equal(t, http.StatusGone, w.Code)
+	})
+
+	t.Run("Invitación revocada", func(t *testing.T) {
+		response := invite("revocado@example.com", "employee")
+		id := response["invitation"].(map[string]interface{})["id"]
+
+		w := MakeRequest("DELETE", fmt.Sprintf("/api/invitations/%v", id), nil, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = MakeRequest("GET", "/api/auth/invitations/"+response["token"].(string), nil, "")
+		assert.Equal(t, http.StatusGone, w.Code)
+	})
+
+	t.Run("Rol inválido", func(t *testing.T) {
+		w := MakeRequest("POST", "/api/invitations", map[string]interface{}{
+			"email": "otro@example.com",
+			"role":  "superuser",
+		}, testToken)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Registro público deshabilitado", func(t *testing.T) {
+		os.Setenv("PUBLIC_REGISTRATION", "false")
+		defer os.Unsetenv("PUBLIC_REGISTRATION")
+
+		w := MakeRequest("POST", "/api/auth/register", map[string]interface{}{
+			"username": "publico",
+			"email":    "publico@example.com",
+			"password": "password123",
+		}, "")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Stormdead/inventory-control-panel/backend/config"
+	"github.com/Stormdead/inventory-control-panel/backend/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocationStock(t *testing.T) {
+	if testToken == "" {
+		t.Skip("No hay token disponible. Ejecuta TestLogin primero")
+	}
+
+	w := MakeRequest("POST", "/api/locations", map[string]interface{}{
+		"name": "Tienda Centro",
+		"code": "TIENDA-01",
+		"type": "tienda",
+	}, testToken)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var created map[string]interface{}
+	ParseResponse(w, &created)
+	locationID := uint(created["location"].(map[string]interface{})["id"].(float64))
+
+	// 5 unidades en la ubicación por defecto y 3 en la tienda
+	productID := createMovementTestProduct(t, "Producto Ubicaciones", 5)
+	w = MakeRequest("POST", "/api/movements", map[string]interface{}{
+		"product_id":  productID,
+		"location_id": locationID,
+		"type":        "entrada",
+		"quantity":    3,
+	}, testToken)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	t.Run("El stock total es la suma de ubicaciones", func(t *testing.T) {
+		w := MakeRequest("GET", fmt.Sprintf("/api/products/%d", productID), nil, testToken)
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+
+		product := response["product"].(map[string]interface{})
+		assert.Equal(t, float64(8), product["stock"])
+		assert.Len(t, product["stocks"], 2)
+	})
+
+	t.Run("Salida mayor al stock de la ubicación", func(t *testing.T) {
+		w := MakeRequest("POST", "/api/movements", map[string]interface{}{
+			"product_id":  productID,
+			"location_id": locationID,
+			"type":        "salida",
+			"quantity":    4,
+		}, testToken)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Estadísticas filtradas por ubicación", func(t *testing.T) {
+		w := MakeRequest("GET", fmt.Sprintf("/api/dashboard/stats?location_id=%d", locationID), nil, testToken)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+
+		stats := response["stats"].(map[string]interface{})
+		assert.Equal(t, float64(3), stats["total_stock"])
+	})
+}
+
+func TestUpdateAndDeleteLocation(t *testing.T) {
+	if testToken == "" {
+		t.Skip("No hay token disponible. Ejecuta TestLogin primero")
+	}
+
+	createLocation := func(code, address string) uint {
+		w := MakeRequest("POST", "/api/locations", map[string]interface{}{"name": code, "code": code, "address": address}, testToken)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		return uint(response["location"].(map[string]interface{})["id"].(float64))
+	}
+	origin := createLocation("LOC-ORIGEN", "Calle 1")
+	destination := createLocation("LOC-DESTINO", "Calle 2")
+
+	t.Run("Actualizar sin address la conserva", func(t *testing.T) {
+		w := MakeRequest("PUT", fmt.Sprintf("/api/locations/%d", origin), map[string]interface{}{"name": "Origen"}, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		location := response["location"].(map[string]interface{})
+		assert.Equal(t, "Origen", location["name"])
+		assert.Equal(t, "Calle 1", location["address"])
+
+		// Enviarla vacía sí la borra
+		w = MakeRequest("PUT", fmt.Sprintf("/api/locations/%d", origin), map[string]interface{}{"address": ""}, testToken)
+		ParseResponse(w, &response)
+		assert.Equal(t, "", response["location"].(map[string]interface{})["address"])
+	})
+
+	t.Run("No se elimina el destino de una transferencia en tránsito", func(t *testing.T) {
+		productID := createMovementTestProduct(t, "Producto Destino Tránsito", 0)
+		w := MakeRequest("POST", "/api/movements", map[string]interface{}{
+			"product_id":  productID,
+			"location_id": origin,
+			"type":        "entrada",
+			"quantity":    2,
+		}, testToken)
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		w = MakeRequest("POST", "/api/transfers", map[string]interface{}{
+			"product_id":       productID,
+			"from_location_id": origin,
+			"to_location_id":   destination,
+			"quantity":         2,
+			"in_transit":       true,
+		}, testToken)
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		w = MakeRequest("DELETE", fmt.Sprintf("/api/locations/%d", destination), nil, testToken)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Eliminar borra sus filas de stock en cero", func(t *testing.T) {
+		empty := createLocation("LOC-VACIA", "")
+		config.DB.Create(&models.ProductStock{ProductID: createMovementTestProduct(t, "Producto Ubicación Vacía", 0), LocationID: empty})
+
+		w := MakeRequest("DELETE", fmt.Sprintf("/api/locations/%d", empty), nil, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var count int64
+		config.DB.Model(&models.ProductStock{}).Where("location_id = ?", empty).Count(&count)
+		assert.Zero(t, count)
+	})
+}
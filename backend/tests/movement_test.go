@@ -1 +1,234 @@
 package tests
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// createMovementTestProduct crea un producto para los tests de movimientos;
+// extra agrega campos al producto (niveles de stock, categoría, costo)
+func createMovementTestProduct(t *testing.T, name string, stock int, extra ...map[string]interface{}) uint {
+	payload := map[string]interface{}{
+		"name":  name,
+		"price": 10.0,
+		"stock": stock,
+	}
+	for _, fields := range extra {
+		for key, value := range fields {
+			payload[key] = value
+		}
+	}
+	w := MakeRequest("POST", "/api/products", payload, testToken)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response map[string]interface{}
+	ParseResponse(w, &response)
+	return uint(response["product"].(map[string]interface{})["id"].(float64))
+}
+
+func TestReverseMovement(t *testing.T) {
+	if testToken == "" {
+		t.Skip("No hay token disponible. Ejecuta TestLogin primero")
+	}
+
+	productID := createMovementTestProduct(t, "Producto Reverso", 0)
+
+	payload := map[string]interface{}{
+		"product_id": productID,
+		"type":       "entrada",
+		"quantity":   5,
+	}
+	w := MakeRequest("POST", "/api/movements", payload, testToken)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var created map[string]interface{}
+	ParseResponse(w, &created)
+	movementID := uint(created["movement"].(map[string]interface{})["id"].(float64))
+
+	t.Run("Revertir entrada", func(t *testing.T) {
+		w := MakeRequest("POST", fmt.Sprintf("/api/movements/%d/reverse", movementID), nil, testToken)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+
+		movement := response["movement"].(map[string]interface{})
+		assert.Equal(t, "reverso_entrada", movement["type"])
+		assert.Equal(t, float64(movementID), movement["reversal_of_id"])
+		assert.Equal(t, float64(0), response["nuevo_stock"])
+	})
+
+	t.Run("Revertir dos veces", func(t *testing.T) {
+		w := MakeRequest("POST", fmt.Sprintf("/api/movements/%d/reverse", movementID), nil, testToken)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Reversos concurrentes", func(t *testing.T) {
+		w := MakeRequest("POST", "/api/movements", payload, testToken)
+		var other map[string]interface{}
+		ParseResponse(w, &other)
+		otherID := uint(other["movement"].(map[string]interface{})["id"].(float64))
+
+		// Solo uno gana; el resto recibe 409, nunca un error interno
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		statuses := map[int]int{}
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				w := MakeRequest("POST", fmt.Sprintf("/api/movements/%d/reverse", otherID), nil, testToken)
+				mu.Lock()
+				statuses[w.Code]++
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, 1, statuses[http.StatusCreated])
+		assert.Equal(t, 4, statuses[http.StatusConflict])
+	})
+
+	t.Run("El movimiento original se conserva", func(t *testing.T) {
+		w := MakeRequest("GET", fmt.Sprintf("/api/movements/%d", movementID), nil, testToken)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestCreateMovementServerFields(t *testing.T) {
+	if testToken == "" {
+		t.Skip("No hay token disponible. Ejecuta TestLogin primero")
+	}
+
+	productID := createMovementTestProduct(t, "Producto Campos Servidor", 0)
+	backdated := time.Now().AddDate(-1, 0, 0)
+
+	// El ID, la fecha y los vínculos a otros documentos no se aceptan del cliente
+	w := MakeRequest("POST", "/api/movements", map[string]interface{}{
+		"id":             999999,
+		"product_id":     productID,
+		"type":           "entrada",
+		"quantity":       2,
+		"movement_date":  backdated.Format(time.RFC3339),
+		"reversal_of_id": 1,
+	}, testToken)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response map[string]interface{}
+	ParseResponse(w, &response)
+	movement := response["movement"].(map[string]interface{})
+	assert.NotEqual(t, float64(999999), movement["id"])
+	assert.Nil(t, movement["reversal_of_id"])
+
+	date, _ := time.Parse(time.RFC3339, movement["movement_date"].(string))
+	assert.WithinDuration(t, time.Now(), date, time.Minute)
+}
+
+func TestConcurrentMovements(t *testing.T) {
+	if testToken == "" {
+		t.Skip("No hay token disponible. Ejecuta TestLogin primero")
+	}
+
+	const initialStock = 20
+	const requests = 30
+
+	productID := createMovementTestProduct(t, "Producto Concurrencia", initialStock)
+
+	// Disparar salidas en paralelo: solo initialStock pueden tener éxito
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	statuses := map[int]int{}
+
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			payload := map[string]interface{}{
+				"product_id": productID,
+				"type":       "salida",
+				"quantity":   1,
+			}
+			w := MakeRequest("POST", "/api/movements", payload, testToken)
+
+			mu.Lock()
+			statuses[w.Code]++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, initialStock, statuses[http.StatusCreated])
+	assert.Equal(t, requests-initialStock, statuses[http.StatusBadRequest])
+
+	w := MakeRequest("GET", fmt.Sprintf("/api/products/%d", productID), nil, testToken)
+	var response map[string]interface{}
+	ParseResponse(w, &response)
+
+	product := response["product"].(map[string]interface{})
+	assert.Equal(t, float64(0), product["stock"])
+}
+
+func TestMovementReasonCodes(t *testing.T) {
+	if testToken == "" {
+		t.Skip("No hay token disponible. Ejecuta TestLogin primero")
+	}
+
+	productID := createMovementTestProduct(t, "Producto Bajas", 10)
+
+	w := MakeRequest("GET", "/api/reason-codes?active=true", nil, testToken)
+	var reasons map[string]interface{}
+	ParseResponse(w, &reasons)
+
+	var damageID float64
+	for _, r := range reasons["reason_codes"].([]interface{}) {
+		reason := r.(map[string]interface{})
+		if reason["code"] == "DANO" {
+			damageID = reason["id"].(float64)
+		}
+	}
+	assert.NotZero(t, damageID)
+
+	t.Run("Baja sin motivo", func(t *testing.T) {
+		w := MakeRequest("POST", "/api/movements", map[string]interface{}{
+			"product_id": productID,
+			"type":       "baja",
+			"quantity":   2,
+		}, testToken)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Baja con motivo", func(t *testing.T) {
+		w := MakeRequest("POST", "/api/movements", map[string]interface{}{
+			"product_id":     productID,
+			"type":           "baja",
+			"quantity":       2,
+			"reason_code_id": damageID,
+		}, testToken)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		assert.Equal(t, float64(8), response["nuevo_stock"])
+	})
+
+	t.Run("Resumen desglosado por motivo", func(t *testing.T) {
+		w := MakeRequest("GET", "/api/dashboard/movement-summary", nil, testToken)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		assert.Contains(t, response, "by_type")
+		assert.NotEmpty(t, response["by_reason"])
+	})
+}
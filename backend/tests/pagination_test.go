@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProductPagination(t *testing.T) {
+	if testToken == "" {
+		t.Skip("No hay token disponible. Ejecuta TestLogin primero")
+	}
+
+	for i := 1; i <= 3; i++ {
+		createMovementTestProduct(t, fmt.Sprintf("Paginado %d", i), i)
+	}
+
+	w := MakeRequest("GET", "/api/products?search=Paginado&sort=-name&limit=2", nil, testToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var first map[string]interface{}
+	ParseResponse(w, &first)
+
+	assert.Equal(t, float64(3), first["total"])
+	products := first["products"].([]interface{})
+	assert.Len(t, products, 2)
+	assert.Equal(t, "Paginado 3", products[0].(map[string]interface{})["name"])
+	assert.NotEmpty(t, first["next_cursor"])
+
+	t.Run("Segunda página", func(t *testing.T) {
+		url := fmt.Sprintf("/api/products?search=Paginado&sort=-name&limit=2&cursor=%s", first["next_cursor"])
+		w := MakeRequest("GET", url, nil, testToken)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+
+		products := response["products"].([]interface{})
+		assert.Len(t, products, 1)
+		assert.Equal(t, "Paginado 1", products[0].(map[string]interface{})["name"])
+		assert.Empty(t, response["next_cursor"])
+	})
+
+	t.Run("Filtro por rango de stock", func(t *testing.T) {
+		w := MakeRequest("GET", "/api/products?search=Paginado&min_stock=2&max_stock=2", nil, testToken)
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		assert.Equal(t, float64(1), response["total"])
+	})
+
+	t.Run("Orden inválido", func(t *testing.T) {
+		w := MakeRequest("GET", "/api/products?sort=password", nil, testToken)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestMovementFilters(t *testing.T) {
+	if testToken == "" {
+		t.Skip("No hay token disponible. Ejecuta TestLogin primero")
+	}
+
+	productID := createMovementTestProduct(t, "Producto Filtros", 4)
+
+	w := MakeRequest("GET", fmt.Sprintf("/api/movements?product_id=%d&type=ajuste_entrada&from=2000-01-01", productID), nil, testToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	ParseResponse(w, &response)
+	assert.Equal(t, float64(1), response["total"])
+
+	w = MakeRequest("GET", "/api/movements?from=ayer", nil, testToken)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
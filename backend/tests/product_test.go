@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"fmt"
 	"net/http"
 	"testing"
 
@@ -78,3 +79,78 @@ func TestGetLowStockProducts(t *testing.T) {
 		assert.Contains(t, response, "products")
 	})
 }
+
+func TestUpdateProductPatch(t *testing.T) {
+	if testToken == "" {
+		t.Skip("No hay token disponible. Ejecuta TestLogin primero")
+	}
+
+	productID := createMovementTestProduct(t, "Producto Parcial", 7)
+	url := fmt.Sprintf("/api/products/%d", productID)
+
+	getProduct := func() map[string]interface{} {
+		w := MakeRequest("GET", url, nil, testToken)
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		return response["product"].(map[string]interface{})
+	}
+
+	t.Run("Solo cambian los campos enviados", func(t *testing.T) {
+		w := MakeRequest("PATCH", url, map[string]interface{}{"description": "Nueva descripción"}, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		product := getProduct()
+		assert.Equal(t, "Nueva descripción", product["description"])
+		assert.Equal(t, "Producto Parcial", product["name"])
+		assert.Equal(t, float64(7), product["stock"])
+		assert.Equal(t, float64(10), product["price"])
+	})
+
+	t.Run("El stock no se puede fijar directamente", func(t *testing.T) {
+		w := MakeRequest("PATCH", url, map[string]interface{}{"stock": 0}, testToken)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		// Reenviar el stock actual sin cambios es válido
+		w = MakeRequest("PUT", url, map[string]interface{}{"name": "Producto Parcial", "stock": 7}, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, float64(7), getProduct()["stock"])
+	})
+
+	t.Run("Null borra los campos opcionales", func(t *testing.T) {
+		w := MakeRequest("PATCH", url, map[string]interface{}{"reorder_point": 3}, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, float64(3), getProduct()["reorder_point"])
+
+		w = MakeRequest("PATCH", url, map[string]interface{}{"reorder_point": nil}, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Nil(t, getProduct()["reorder_point"])
+	})
+
+	t.Run("Cambiar el precio requiere permiso", func(t *testing.T) {
+		w := MakeRequest("POST", "/api/users", map[string]interface{}{
+			"username": "empleado_precios",
+			"email":    "empleado.precios@example.com",
+			"password": "password123",
+			"role":     "employee",
+		}, testToken)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		w = MakeRequest("POST", "/api/auth/login", map[string]interface{}{
+			"email":    "empleado.precios@example.com",
+			"password": "password123",
+		}, "")
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		employeeToken := response["token"].(string)
+
+		w = MakeRequest("PATCH", url, map[string]interface{}{"price": 25}, employeeToken)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		// Con el precio sin cambios el empleado puede editar el resto
+		w = MakeRequest("PATCH", url, map[string]interface{}{"price": 10, "name": "Producto Editado"}, employeeToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = MakeRequest("PATCH", url, map[string]interface{}{"price": 25}, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, float64(25), getProduct()["price"])
+	})
+}
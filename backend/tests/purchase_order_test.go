@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurchaseOrders(t *testing.T) {
+	if testToken == "" {
+		t.Skip("No hay token disponible. Ejecuta TestLogin primero")
+	}
+
+	productID := createMovementTestProduct(t, "Producto Compra", 0)
+
+	w := MakeRequest("POST", "/api/suppliers", map[string]interface{}{
+		"name":   "Distribuidora Test",
+		"tax_id": "30-12345678-9",
+	}, testToken)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response map[string]interface{}
+	ParseResponse(w, &response)
+	supplierID := response["supplier"].(map[string]interface{})["id"]
+
+	w = MakeRequest("POST", "/api/purchase-orders", map[string]interface{}{
+		"supplier_id":   supplierID,
+		"expected_date": "2030-01-15",
+		"lines": []map[string]interface{}{
+			{"product_id": productID, "quantity": 10, "unit_cost": 4.5},
+		},
+	}, testToken)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	ParseResponse(w, &response)
+	order := response["purchase_order"].(map[string]interface{})
+	orderID := uint(order["id"].(float64))
+	lineID := order["lines"].([]interface{})[0].(map[string]interface{})["id"]
+
+	productOnOrder := func() (float64, float64) {
+		w := MakeRequest("GET", fmt.Sprintf("/api/products/%d", productID), nil, testToken)
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		product := response["product"].(map[string]interface{})
+		return product["stock"].(float64), product["on_order"].(float64)
+	}
+
+	t.Run("La orden abierta suma en pedido", func(t *testing.T) {
+		stock, onOrder := productOnOrder()
+		assert.Equal(t, float64(0), stock)
+		assert.Equal(t, float64(10), onOrder)
+	})
+
+	t.Run("Recepción parcial", func(t *testing.T) {
+		w := MakeRequest("POST", fmt.Sprintf("/api/purchase-orders/%d/receive", orderID), map[string]interface{}{
+			"lines": []map[string]interface{}{{"line_id": lineID, "quantity": 4}},
+		}, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		assert.Equal(t, "parcial", response["purchase_order"].(map[string]interface{})["status"])
+
+		receipt := response["receipts"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "entrada", receipt["type"])
+		assert.Equal(t, lineID, receipt["purchase_order_line_id"])
+
+		stock, onOrder := productOnOrder()
+		assert.Equal(t, float64(4), stock)
+		assert.Equal(t, float64(6), onOrder)
+	})
+
+	t.Run("No se puede recibir más de lo pendiente", func(t *testing.T) {
+		w := MakeRequest("POST", fmt.Sprintf("/api/purchase-orders/%d/receive", orderID), map[string]interface{}{
+			"lines": []map[string]interface{}{{"line_id": lineID, "quantity": 7}},
+		}, testToken)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Recepción final completa la orden", func(t *testing.T) {
+		w := MakeRequest("POST", fmt.Sprintf("/api/purchase-orders/%d/receive", orderID), map[string]interface{}{
+			"lines": []map[string]interface{}{{"line_id": lineID, "quantity": 6}},
+		}, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		assert.Equal(t, "recibida", response["purchase_order"].(map[string]interface{})["status"])
+
+		_, onOrder := productOnOrder()
+		assert.Equal(t, float64(0), onOrder)
+	})
+
+	t.Run("Un proveedor con órdenes no se puede eliminar", func(t *testing.T) {
+		w := MakeRequest("DELETE", fmt.Sprintf("/api/suppliers/%v", supplierID), nil, testToken)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Stormdead/inventory-control-panel/backend/config"
+	"github.com/Stormdead/inventory-control-panel/backend/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStockReconciliation(t *testing.T) {
+	if testToken == "" {
+		t.Skip("No hay token disponible. Ejecuta TestLogin primero")
+	}
+
+	productID := createMovementTestProduct(t, "Producto Reconciliación", 8)
+
+	// Simular una edición directa del stock fuera de los movimientos
+	config.DB.Model(&models.Product{}).Where("id = ?", productID).Update("stock", 11)
+
+	findProduct := func(resp map[string]interface{}) map[string]interface{} {
+		for _, d := range resp["discrepancies"].([]interface{}) {
+			discrepancy := d.(map[string]interface{})
+			if uint(discrepancy["product_id"].(float64)) == productID {
+				return discrepancy
+			}
+		}
+		return nil
+	}
+
+	t.Run("Reportar diferencias", func(t *testing.T) {
+		w := MakeRequest("GET", "/api/stock/reconciliation", nil, testToken)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+
+		discrepancy := findProduct(response)
+		assert.NotNil(t, discrepancy)
+		assert.Equal(t, float64(11), discrepancy["stored_stock"])
+		assert.Equal(t, float64(8), discrepancy["ledger_stock"])
+		assert.Equal(t, float64(3), discrepancy["difference"])
+	})
+
+	t.Run("Corregir diferencias", func(t *testing.T) {
+		w := MakeRequest("POST", "/api/stock/reconciliation", nil, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = MakeRequest("GET", "/api/stock/reconciliation", nil, testToken)
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+
+		assert.Nil(t, findProduct(response))
+	})
+
+	t.Run("Corregir diferencias por ubicación", func(t *testing.T) {
+		locationProductID := createMovementTestProduct(t, "Producto Reconciliación Ubicación", 6)
+
+		// El stock de la ubicación se desvía de los movimientos; el total se mantiene derivado
+		config.DB.Model(&models.ProductStock{}).Where("product_id = ?", locationProductID).Update("quantity", 4)
+		config.DB.Model(&models.Product{}).Where("id = ?", locationProductID).Update("stock", 4)
+
+		w := MakeRequest("GET", "/api/stock/reconciliation", nil, testToken)
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		var discrepancy map[string]interface{}
+		for _, d := range response["discrepancies"].([]interface{}) {
+			if uint(d.(map[string]interface{})["product_id"].(float64)) == locationProductID {
+				discrepancy = d.(map[string]interface{})
+			}
+		}
+		assert.NotNil(t, discrepancy)
+		assert.NotZero(t, discrepancy["location_id"])
+		assert.Equal(t, float64(-2), discrepancy["difference"])
+
+		w = MakeRequest("POST", "/api/stock/reconciliation", nil, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		// El ajuste lleva ubicación y costo, y el stock guardado no cambia
+		var adjustment models.Movement
+		config.DB.Where("product_id = ? AND type = ?", locationProductID, models.MovementTypeAjusteSalida).First(&adjustment)
+		assert.Equal(t, 2, adjustment.Quantity)
+		assert.NotNil(t, adjustment.LocationID)
+		assert.NotNil(t, adjustment.UnitCost)
+
+		var stock models.ProductStock
+		config.DB.Where("product_id = ? AND location_id = ?", locationProductID, *adjustment.LocationID).First(&stock)
+		assert.Equal(t, 4, stock.Quantity)
+		var product models.Product
+		config.DB.First(&product, locationProductID)
+		assert.Equal(t, 4, product.Stock)
+	})
+}
@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// productIDsIn devuelve los product_id (o id) de una lista de la respuesta
+func productIDsIn(response map[string]interface{}, key string) []uint {
+	var ids []uint
+	for _, item := range response["products"].([]interface{}) {
+		ids = append(ids, uint(item.(map[string]interface{})[key].(float64)))
+	}
+	return ids
+}
+
+func TestReorderPoints(t *testing.T) {
+	if testToken == "" {
+		t.Skip("No hay token disponible. Ejecuta TestLogin primero")
+	}
+
+	w := MakeRequest("POST", "/api/categories", map[string]interface{}{
+		"name":                  "Tornillos",
+		"default_reorder_point": 50,
+	}, testToken)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var response map[string]interface{}
+	ParseResponse(w, &response)
+	categoryID := response["category"].(map[string]interface{})["id"]
+
+	screwID := createMovementTestProduct(t, "Tornillo", 20, map[string]interface{}{"category_id": categoryID})
+	forkliftID := createMovementTestProduct(t, "Autoelevador", 2, map[string]interface{}{"reorder_point": 1})
+	boxID := createMovementTestProduct(t, "Caja", 5, map[string]interface{}{"reorder_point": 8, "max_stock": 20})
+
+	t.Run("Stock bajo según el punto de pedido de cada producto", func(t *testing.T) {
+		w := MakeRequest("GET", "/api/products/low-stock", nil, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		ids := productIDsIn(response, "id")
+		assert.Contains(t, ids, screwID)
+		assert.Contains(t, ids, boxID)
+		assert.NotContains(t, ids, forkliftID)
+	})
+
+	t.Run("Sugerencia de reposición hasta el máximo", func(t *testing.T) {
+		w := MakeRequest("GET", "/api/products/replenishment", nil, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		assert.NotContains(t, productIDsIn(response, "product_id"), forkliftID)
+
+		for _, item := range response["products"].([]interface{}) {
+			line := item.(map[string]interface{})
+			if uint(line["product_id"].(float64)) == boxID {
+				assert.Equal(t, float64(5), line["position"])
+				assert.Equal(t, float64(15), line["suggested_quantity"])
+			}
+		}
+	})
+
+	t.Run("Niveles inconsistentes", func(t *testing.T) {
+		w := MakeRequest("POST", "/api/products", map[string]interface{}{
+			"name":          "Producto Niveles",
+			"price":         10.0,
+			"min_stock":     10,
+			"reorder_point": 5,
+		}, testToken)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
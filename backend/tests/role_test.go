@@ -0,0 +1,187 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRolesAndPermissions(t *testing.T) {
+	if testToken == "" {
+		t.Skip("No hay token disponible. Ejecuta TestLogin primero")
+	}
+
+	// createUserWithRole crea un usuario con el rol dado y devuelve su ID y token
+	createUserWithRole := func(username, role string) (interface{}, string) {
+		email := username + "@example.com"
+		w := MakeRequest("POST", "/api/users", map[string]interface{}{
+			"username": username,
+			"email":    email,
+			"password": "password123",
+			"role":     role,
+		}, testToken)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		userID := response["user"].(map[string]interface{})["id"]
+
+		w = MakeRequest("POST", "/api/auth/login", map[string]interface{}{
+			"email":    email,
+			"password": "password123",
+		}, "")
+		ParseResponse(w, &response)
+		return userID, response["token"].(string)
+	}
+
+	t.Run("El auditor solo puede leer y exportar", func(t *testing.T) {
+		_, token := createUserWithRole("auditor_test", "auditor")
+
+		w := MakeRequest("GET", "/api/products", nil, token)
+		assert.Equal(t, http.StatusOK, w.Code)
+		w = MakeRequest("GET", "/api/movements/export", nil, token)
+		assert.Equal(t, http.StatusOK, w.Code)
+		w = MakeRequest("POST", "/api/categories", map[string]interface{}{"name": "Auditoría"}, token)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		w = MakeRequest("POST", "/api/movements", map[string]interface{}{"product_id": 1, "type": "entrada", "quantity": 1}, token)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Rol personalizado editable en caliente", func(t *testing.T) {
+		w := MakeRequest("POST", "/api/roles", map[string]interface{}{
+			"name":        "catalogador",
+			"description": "Mantiene el catálogo",
+			"permissions": "categories:write",
+		}, testToken)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		roleID := response["role"].(map[string]interface{})["id"]
+
+		_, token := createUserWithRole("catalogador_test", "catalogador")
+		w = MakeRequest("POST", "/api/categories", map[string]interface{}{"name": "Catalogada"}, token)
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		// Quitar el permiso aplica en la siguiente petición
+		w = MakeRequest("PUT", fmt.Sprintf("/api/roles/%v", roleID), map[string]interface{}{"permissions": ""}, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+		w = MakeRequest("POST", "/api/categories", map[string]interface{}{"name": "Catalogada 2"}, token)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		// Un rol asignado no se puede eliminar
+		w = MakeRequest("DELETE", fmt.Sprintf("/api/roles/%v", roleID), nil, testToken)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Validaciones", func(t *testing.T) {
+		w := MakeRequest("POST", "/api/roles", map[string]interface{}{
+			"name":        "invalido",
+			"permissions": "products:fly",
+		}, testToken)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = MakeRequest("POST", "/api/users", map[string]interface{}{
+			"username": "sin_rol",
+			"email":    "sin.rol@example.com",
+			"password": "password123",
+			"role":     "no_existe",
+		}, testToken)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = MakeRequest("GET", "/api/roles", nil, testToken)
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		for _, item := range response["roles"].([]interface{}) {
+			role := item.(map[string]interface{})
+			if role["name"] == "admin" {
+				w = MakeRequest("DELETE", fmt.Sprintf("/api/roles/%v", role["id"]), nil, testToken)
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				w = MakeRequest("PUT", fmt.Sprintf("/api/roles/%v", role["id"]), map[string]interface{}{"permissions": ""}, testToken)
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+			}
+		}
+	})
+
+	t.Run("No se pueden otorgar permisos que no se tienen", func(t *testing.T) {
+		w := MakeRequest("POST", "/api/roles", map[string]interface{}{
+			"name":        "gestor_usuarios",
+			"permissions": "users:manage,roles:manage",
+		}, testToken)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		roleID := response["role"].(map[string]interface{})["id"]
+
+		userID, token := createUserWithRole("gestor_test", "gestor_usuarios")
+
+		w = MakeRequest("GET", "/api/auth/profile", nil, testToken)
+		ParseResponse(w, &response)
+		adminID := response["user"].(map[string]interface{})["id"]
+
+		// Asignar admin a un usuario nuevo, a sí mismo o a una invitación
+		w = MakeRequest("POST", "/api/users", map[string]interface{}{
+			"username": "escalada",
+			"email":    "escalada@example.com",
+			"password": "password123",
+			"role":     "admin",
+		}, token)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		w = MakeRequest("PUT", fmt.Sprintf("/api/users/%v", userID), map[string]interface{}{"role": "admin"}, token)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		w = MakeRequest("POST", "/api/invitations", map[string]interface{}{
+			"email": "escalada@example.com",
+			"role":  "admin",
+		}, token)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		// Tampoco un rol con permisos que no tiene
+		w = MakeRequest("POST", "/api/users", map[string]interface{}{
+			"username": "escalada",
+			"email":    "escalada@example.com",
+			"password": "password123",
+			"role":     "store_manager",
+		}, token)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		// No puede gestionar a un admin
+		w = MakeRequest("POST", fmt.Sprintf("/api/users/%v/password", adminID), map[string]interface{}{"password": "tomada123"}, token)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		w = MakeRequest("PUT", fmt.Sprintf("/api/users/%v", adminID), map[string]interface{}{"active": false}, token)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		w = MakeRequest("DELETE", fmt.Sprintf("/api/users/%v", adminID), nil, token)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		// Ni agregarse permisos a su rol o a uno nuevo
+		w = MakeRequest("PUT", fmt.Sprintf("/api/roles/%v", roleID), map[string]interface{}{
+			"permissions": "users:manage,roles:manage,products:write",
+		}, token)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		w = MakeRequest("POST", "/api/roles", map[string]interface{}{
+			"name":        "escalada",
+			"permissions": "products:write",
+		}, token)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		// Dentro de sus propios permisos sí puede
+		w = MakeRequest("POST", "/api/roles", map[string]interface{}{
+			"name":        "solo_usuarios",
+			"permissions": "users:manage",
+		}, token)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		w = MakeRequest("POST", "/api/users", map[string]interface{}{
+			"username": "delegado",
+			"email":    "delegado@example.com",
+			"password": "password123",
+			"role":     "solo_usuarios",
+		}, token)
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("El perfil incluye los permisos", func(t *testing.T) {
+		w := MakeRequest("GET", "/api/auth/profile", nil, testToken)
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		assert.Contains(t, response["user"].(map[string]interface{})["permissions"], "*")
+	})
+}
@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSalesOrders(t *testing.T) {
+	if testToken == "" {
+		t.Skip("No hay token disponible. Ejecuta TestLogin primero")
+	}
+
+	productID := createMovementTestProduct(t, "Producto Pedido", 10)
+
+	createOrder := func(quantity int) (uint, interface{}) {
+		w := MakeRequest("POST", "/api/sales-orders", map[string]interface{}{
+			"customer": "Cliente Test",
+			"lines": []map[string]interface{}{
+				{"product_id": productID, "quantity": quantity, "unit_price": 20},
+			},
+		}, testToken)
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		order := response["sales_order"].(map[string]interface{})
+		assert.Equal(t, "borrador", order["status"])
+		return uint(order["id"].(float64)), order["lines"].([]interface{})[0].(map[string]interface{})["id"]
+	}
+
+	productAvailability := func() (float64, float64, float64) {
+		w := MakeRequest("GET", fmt.Sprintf("/api/products/%d", productID), nil, testToken)
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		product := response["product"].(map[string]interface{})
+		return product["stock"].(float64), product["reserved"].(float64), product["available"].(float64)
+	}
+
+	orderID, lineID := createOrder(6)
+
+	t.Run("El borrador no reserva", func(t *testing.T) {
+		_, reserved, available := productAvailability()
+		assert.Equal(t, float64(0), reserved)
+		assert.Equal(t, float64(10), available)
+	})
+
+	t.Run("Confirmar reserva el stock", func(t *testing.T) {
+		w := MakeRequest("POST", fmt.Sprintf("/api/sales-orders/%d/confirm", orderID), nil, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		stock, reserved, available := productAvailability()
+		assert.Equal(t, float64(10), stock)
+		assert.Equal(t, float64(6), reserved)
+		assert.Equal(t, float64(4), available)
+	})
+
+	t.Run("No se confirma un pedido sin disponible", func(t *testing.T) {
+		otherID, _ := createOrder(5)
+		w := MakeRequest("POST", fmt.Sprintf("/api/sales-orders/%d/confirm", otherID), nil, testToken)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Una salida común no usa lo reservado", func(t *testing.T) {
+		w := MakeRequest("POST", "/api/movements", map[string]interface{}{
+			"product_id": productID,
+			"type":       "salida",
+			"quantity":   5,
+		}, testToken)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		assert.Equal(t, float64(6), response["reservado"])
+		assert.Equal(t, float64(4), response["disponible"])
+	})
+
+	t.Run("Despacho parcial convierte la reserva en salida", func(t *testing.T) {
+		w := MakeRequest("POST", fmt.Sprintf("/api/sales-orders/%d/fulfill", orderID), map[string]interface{}{
+			"lines": []map[string]interface{}{{"line_id": lineID, "quantity": 2}},
+		}, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		assert.Equal(t, "parcial", response["sales_order"].(map[string]interface{})["status"])
+
+		shipment := response["shipments"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "salida", shipment["type"])
+		assert.Equal(t, lineID, shipment["sales_order_line_id"])
+
+		stock, reserved, available := productAvailability()
+		assert.Equal(t, float64(8), stock)
+		assert.Equal(t, float64(4), reserved)
+		assert.Equal(t, float64(4), available)
+	})
+
+	t.Run("Cancelar libera la reserva", func(t *testing.T) {
+		w := MakeRequest("POST", fmt.Sprintf("/api/sales-orders/%d/cancel", orderID), nil, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		stock, reserved, available := productAvailability()
+		assert.Equal(t, float64(8), stock)
+		assert.Equal(t, float64(0), reserved)
+		assert.Equal(t, float64(8), available)
+	})
+
+	t.Run("No se despacha un pedido cancelado", func(t *testing.T) {
+		w := MakeRequest("POST", fmt.Sprintf("/api/sales-orders/%d/fulfill", orderID), map[string]interface{}{}, testToken)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
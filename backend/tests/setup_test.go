@@ -3,6 +3,7 @@ package tests
 import (
 	"bytes"
 	"encoding/json"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 
@@ -36,6 +37,11 @@ func SetupTestEnvironment() {
 
 // MakeRequest es un helper para hacer peticiones HTTP
 func MakeRequest(method, url string, body interface{}, token string) *httptest.ResponseRecorder {
+	return MakeRequestWithHeaders(method, url, body, token, nil)
+}
+
+// MakeRequestWithHeaders es como MakeRequest pero permite cabeceras adicionales
+func MakeRequestWithHeaders(method, url string, body interface{}, token string, headers map[string]string) *httptest.ResponseRecorder {
 	var reqBody []byte
 	var err error
 
@@ -53,6 +59,34 @@ func MakeRequest(method, url string, body interface{}, token string) *httptest.R
 		req.Header.Set("Authorization", "Bearer "+token)
 	}
 
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	return w
+}
+
+// MakeUploadRequest envía un archivo y campos de formulario como multipart/form-data
+func MakeUploadRequest(url, filename string, content []byte, fields map[string]string, token string) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	for key, value := range fields {
+		writer.WriteField(key, value)
+	}
+	part, _ := writer.CreateFormFile("file", filename)
+	part.Write(content)
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", url, &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
@@ -66,8 +100,26 @@ func ParseResponse(w *httptest.ResponseRecorder, target interface{}) error {
 
 // CleanupDatabase limpia la base de datos después de los tests
 func CleanupDatabase() {
+	config.DB.Exec("DELETE FROM idempotency_keys")
+	config.DB.Exec("DELETE FROM count_lines")
+	config.DB.Exec("DELETE FROM count_sessions")
+	config.DB.Exec("DELETE FROM stock_snapshot_lines")
+	config.DB.Exec("DELETE FROM stock_snapshots")
+	config.DB.Exec("DELETE FROM cost_layers")
 	config.DB.Exec("DELETE FROM movements")
+	config.DB.Exec("DELETE FROM sales_order_lines")
+	config.DB.Exec("DELETE FROM sales_orders")
+	config.DB.Exec("DELETE FROM purchase_order_lines")
+	config.DB.Exec("DELETE FROM purchase_orders")
+	config.DB.Exec("DELETE FROM suppliers")
+	config.DB.Exec("DELETE FROM transfers")
+	config.DB.Exec("DELETE FROM product_stocks")
+	config.DB.Exec("DELETE FROM product_barcodes")
+	config.DB.Exec("DELETE FROM locations WHERE is_default = false")
 	config.DB.Exec("DELETE FROM products")
 	config.DB.Exec("DELETE FROM categories")
+	config.DB.Exec("DELETE FROM refresh_tokens")
+	config.DB.Exec("DELETE FROM invitations")
+	config.DB.Exec("DELETE FROM roles WHERE `system` = false")
 	config.DB.Exec("DELETE FROM users")
 }
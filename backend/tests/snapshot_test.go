@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Stormdead/inventory-control-panel/backend/config"
+	"github.com/Stormdead/inventory-control-panel/backend/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// stockAsOf devuelve el stock de un producto según GET /api/stock/as-of
+func stockAsOf(t *testing.T, productID uint, asOf string) float64 {
+	w := MakeRequest("GET", "/api/stock/as-of?as_of="+asOf, nil, testToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	ParseResponse(w, &response)
+
+	for _, p := range response["products"].([]interface{}) {
+		p := p.(map[string]interface{})
+		if uint(p["product_id"].(float64)) == productID {
+			return p["quantity"].(float64)
+		}
+	}
+	return 0
+}
+
+func TestStockSnapshots(t *testing.T) {
+	if testToken == "" {
+		t.Skip("No hay token disponible. Ejecuta TestLogin primero")
+	}
+
+	productID := createMovementTestProduct(t, "Producto Histórico", 0)
+	lastMonth := time.Now().AddDate(0, -1, 0)
+
+	// Movimiento fechado el mes pasado (la API fecha al registrar; se simula en la base) y otro de hoy
+	w := MakeRequest("POST", "/api/movements", map[string]interface{}{
+		"product_id": productID,
+		"type":       "entrada",
+		"quantity":   7,
+	}, testToken)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created map[string]interface{}
+	ParseResponse(w, &created)
+	config.DB.Model(&models.Movement{}).Where("id = ?", created["movement"].(map[string]interface{})["id"]).
+		Update("movement_date", lastMonth)
+
+	w = MakeRequest("POST", "/api/movements", map[string]interface{}{
+		"product_id": productID,
+		"type":       "entrada",
+		"quantity":   3,
+	}, testToken)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	t.Run("Stock a una fecha desde los movimientos", func(t *testing.T) {
+		assert.Equal(t, float64(7), stockAsOf(t, productID, lastMonth.Format("2006-01-02")))
+		assert.Equal(t, float64(10), stockAsOf(t, productID, time.Now().Format("2006-01-02")))
+	})
+
+	var snapshotID uint
+	t.Run("Cierre de mes", func(t *testing.T) {
+		w := MakeRequest("POST", "/api/stock/snapshots", map[string]interface{}{
+			"month": lastMonth.Format("2006-01"),
+		}, testToken)
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		snapshotID = uint(response["snapshot"].(map[string]interface{})["id"].(float64))
+
+		// El resultado con la foto es el mismo que desde los movimientos
+		assert.Equal(t, float64(10), stockAsOf(t, productID, time.Now().Format("2006-01-02")))
+
+		w = MakeRequest("POST", "/api/stock/snapshots", map[string]interface{}{
+			"month": lastMonth.Format("2006-01"),
+		}, testToken)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Un cierre bloqueado no admite movimientos con fecha anterior", func(t *testing.T) {
+		w := MakeRequest("POST", fmt.Sprintf("/api/stock/snapshots/%d/lock", snapshotID), nil, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		// La fecha enviada se ignora: el movimiento queda con la fecha actual, fuera del cierre
+		w = MakeRequest("POST", "/api/movements", map[string]interface{}{
+			"product_id":    productID,
+			"type":          "salida",
+			"quantity":      1,
+			"movement_date": lastMonth.Format(time.RFC3339),
+		}, testToken)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, float64(7), stockAsOf(t, productID, lastMonth.Format("2006-01-02")))
+
+		w = MakeRequest("DELETE", fmt.Sprintf("/api/stock/snapshots/%d", snapshotID), nil, testToken)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// productLocationStock devuelve el stock de un producto en una ubicación
+func productLocationStock(t *testing.T, productID, locationID uint) float64 {
+	w := MakeRequest("GET", fmt.Sprintf("/api/products/%d", productID), nil, testToken)
+	var response map[string]interface{}
+	ParseResponse(w, &response)
+
+	stocks, _ := response["product"].(map[string]interface{})["stocks"].([]interface{})
+	for _, s := range stocks {
+		stock := s.(map[string]interface{})
+		if uint(stock["location_id"].(float64)) == locationID {
+			return stock["quantity"].(float64)
+		}
+	}
+	return 0
+}
+
+func TestTransfers(t *testing.T) {
+	if testToken == "" {
+		t.Skip("No hay token disponible. Ejecuta TestLogin primero")
+	}
+
+	createLocation := func(code string) uint {
+		w := MakeRequest("POST", "/api/locations", map[string]interface{}{"name": code, "code": code}, testToken)
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		return uint(response["location"].(map[string]interface{})["id"].(float64))
+	}
+	origin := createLocation("TR-ORIGEN")
+	destination := createLocation("TR-DESTINO")
+
+	productID := createMovementTestProduct(t, "Producto Transferencia", 0)
+	w := MakeRequest("POST", "/api/movements", map[string]interface{}{
+		"product_id":  productID,
+		"location_id": origin,
+		"type":        "entrada",
+		"quantity":    10,
+	}, testToken)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var transferID uint
+
+	t.Run("Despachar transferencia en tránsito", func(t *testing.T) {
+		w := MakeRequest("POST", "/api/transfers", map[string]interface{}{
+			"product_id":       productID,
+			"from_location_id": origin,
+			"to_location_id":   destination,
+			"quantity":         6,
+			"in_transit":       true,
+		}, testToken)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+
+		transfer := response["transfer"].(map[string]interface{})
+		transferID = uint(transfer["id"].(float64))
+		assert.Equal(t, "en_transito", transfer["status"])
+		assert.Equal(t, float64(4), productLocationStock(t, productID, origin))
+		assert.Equal(t, float64(0), productLocationStock(t, productID, destination))
+	})
+
+	t.Run("Recibir transferencia", func(t *testing.T) {
+		w := MakeRequest("POST", fmt.Sprintf("/api/transfers/%d/receive", transferID), nil, testToken)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+
+		transfer := response["transfer"].(map[string]interface{})
+		assert.Equal(t, "recibida", transfer["status"])
+		assert.Len(t, transfer["movements"], 2)
+		assert.Equal(t, float64(6), productLocationStock(t, productID, destination))
+	})
+
+	t.Run("No se puede recibir dos veces", func(t *testing.T) {
+		w := MakeRequest("POST", fmt.Sprintf("/api/transfers/%d/receive", transferID), nil, testToken)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Transferencia sin stock suficiente", func(t *testing.T) {
+		w := MakeRequest("POST", "/api/transfers", map[string]interface{}{
+			"product_id":       productID,
+			"from_location_id": origin,
+			"to_location_id":   destination,
+			"quantity":         5,
+		}, testToken)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
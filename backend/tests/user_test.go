@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserManagement(t *testing.T) {
+	if testToken == "" {
+		t.Skip("No hay token disponible. Ejecuta TestLogin primero")
+	}
+
+	login := func(email, password string) (int, string) {
+		w := MakeRequest("POST", "/api/auth/login", map[string]interface{}{
+			"email":    email,
+			"password": password,
+		}, "")
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		token, _ := response["token"].(string)
+		return w.Code, token
+	}
+
+	w := MakeRequest("POST", "/api/users", map[string]interface{}{
+		"username": "empleado_gestion",
+		"email":    "empleado.gestion@example.com",
+		"password": "password123",
+		"role":     "employee",
+	}, testToken)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var response map[string]interface{}
+	ParseResponse(w, &response)
+	userID := response["user"].(map[string]interface{})["id"]
+	userURL := fmt.Sprintf("/api/users/%v", userID)
+
+	t.Run("Listado con búsqueda y paginación", func(t *testing.T) {
+		w := MakeRequest("GET", "/api/users?search=gestion&limit=1", nil, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		assert.Len(t, response["users"], 1)
+		assert.Equal(t, float64(1), response["total"])
+	})
+
+	t.Run("Un empleado no puede gestionar usuarios", func(t *testing.T) {
+		_, token := login("empleado.gestion@example.com", "password123")
+		w := MakeRequest("GET", "/api/users", nil, token)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Cambio de rol aplica de inmediato", func(t *testing.T) {
+		_, token := login("empleado.gestion@example.com", "password123")
+
+		w := MakeRequest("PUT", userURL, map[string]interface{}{"role": "admin"}, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+		w = MakeRequest("GET", "/api/users", nil, token)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = MakeRequest("PUT", userURL, map[string]interface{}{"role": "employee"}, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Desactivar rechaza el token de inmediato", func(t *testing.T) {
+		_, token := login("empleado.gestion@example.com", "password123")
+
+		w := MakeRequest("PUT", userURL, map[string]interface{}{"active": false}, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = MakeRequest("GET", "/api/auth/profile", nil, token)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		code, _ := login("empleado.gestion@example.com", "password123")
+		assert.Equal(t, http.StatusForbidden, code)
+
+		w = MakeRequest("PUT", userURL, map[string]interface{}{"active": true}, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Restablecer contraseña", func(t *testing.T) {
+		w := MakeRequest("POST", userURL+"/password", map[string]interface{}{"password": "nueva123"}, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		code, _ := login("empleado.gestion@example.com", "password123")
+		assert.Equal(t, http.StatusUnauthorized, code)
+		code, _ = login("empleado.gestion@example.com", "nueva123")
+		assert.Equal(t, http.StatusOK, code)
+	})
+
+	t.Run("El último admin no puede quitarse el rol", func(t *testing.T) {
+		w := MakeRequest("GET", "/api/auth/profile", nil, testToken)
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		adminID := response["user"].(map[string]interface{})["id"]
+
+		w = MakeRequest("PUT", fmt.Sprintf("/api/users/%v", adminID), map[string]interface{}{"role": "employee"}, testToken)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Eliminar conserva el historial", func(t *testing.T) {
+		_, token := login("empleado.gestion@example.com", "nueva123")
+		productID := createMovementTestProduct(t, "Producto Usuario Eliminado", 0)
+		w := MakeRequest("POST", "/api/movements", map[string]interface{}{
+			"product_id": productID,
+			"type":       "entrada",
+			"quantity":   1,
+		}, token)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		movementID := response["movement"].(map[string]interface{})["id"]
+
+		w = MakeRequest("DELETE", userURL, nil, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = MakeRequest("GET", fmt.Sprintf("/api/movements/%v", movementID), nil, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+		ParseResponse(w, &response)
+		user := response["movement"].(map[string]interface{})["user"].(map[string]interface{})
+		assert.Equal(t, "empleado_gestion", user["username"])
+		assert.NotNil(t, user["deleted_at"])
+
+		code, _ := login("empleado.gestion@example.com", "nueva123")
+		assert.Equal(t, http.StatusUnauthorized, code)
+	})
+}
@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInventoryValuation(t *testing.T) {
+	if testToken == "" {
+		t.Skip("No hay token disponible. Ejecuta TestLogin primero")
+	}
+
+	productID := createMovementTestProduct(t, "Producto Valuación", 0)
+
+	for _, cost := range []float64{5, 8} {
+		w := MakeRequest("POST", "/api/movements", map[string]interface{}{
+			"product_id": productID,
+			"type":       "entrada",
+			"quantity":   10,
+			"unit_cost":  cost,
+		}, testToken)
+		assert.Equal(t, http.StatusCreated, w.Code)
+	}
+
+	t.Run("La salida registra el costo de venta por ambos métodos", func(t *testing.T) {
+		w := MakeRequest("POST", "/api/movements", map[string]interface{}{
+			"product_id": productID,
+			"type":       "salida",
+			"quantity":   15,
+		}, testToken)
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+
+		movement := response["movement"].(map[string]interface{})
+		assert.Equal(t, 97.5, movement["cost_amount"]) // 15 x 6.5 de costo promedio
+		assert.Equal(t, 90.0, movement["fifo_cost"])   // 10 x 5 + 5 x 8
+	})
+
+	t.Run("Valuación por costo promedio y FIFO", func(t *testing.T) {
+		expected := map[string]float64{"promedio": 32.5, "fifo": 40}
+
+		for method, value := range expected {
+			w := MakeRequest("GET", "/api/dashboard/valuation?method="+method, nil, testToken)
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			var response map[string]interface{}
+			ParseResponse(w, &response)
+
+			found := false
+			for _, line := range response["products"].([]interface{}) {
+				line := line.(map[string]interface{})
+				if uint(line["product_id"].(float64)) == productID {
+					found = true
+					assert.Equal(t, float64(5), line["quantity"], method)
+					assert.Equal(t, value, line["value"], method)
+				}
+			}
+			assert.True(t, found, fmt.Sprintf("el producto no aparece en la valuación %s", method))
+		}
+	})
+
+	t.Run("Valuación antes de los movimientos", func(t *testing.T) {
+		w := MakeRequest("GET", "/api/dashboard/valuation?as_of=2000-01-01", nil, testToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		ParseResponse(w, &response)
+		assert.Equal(t, float64(0), response["total_value"])
+	})
+}
@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Simbologías de código de barras soportadas
+const (
+	SymbologyEAN13   = "ean13"
+	SymbologyUPCA    = "upca"
+	SymbologyCode128 = "code128"
+)
+
+// DetectSymbology deduce la simbología de un código: 13 dígitos es EAN-13,
+// 12 dígitos es UPC-A y cualquier otro valor se trata como Code128
+func DetectSymbology(code string) string {
+	if isDigits(code) {
+		switch len(code) {
+		case 13:
+			return SymbologyEAN13
+		case 12:
+			return SymbologyUPCA
+		}
+	}
+	return SymbologyCode128
+}
+
+// ValidateBarcode verifica el formato y el dígito de control de un código
+func ValidateBarcode(code, symbology string) error {
+	switch symbology {
+	case SymbologyEAN13, SymbologyUPCA:
+		length := 13
+		if symbology == SymbologyUPCA {
+			length = 12
+		}
+		if len(code) != length || !isDigits(code) {
+			return fmt.Errorf("el código debe tener exactamente %d dígitos", length)
+		}
+		if !validGS1CheckDigit(code) {
+			return errors.New("dígito de control inválido")
+		}
+	case SymbologyCode128:
+		if len(code) == 0 || len(code) > 48 {
+			return errors.New("el código Code128 debe tener entre 1 y 48 caracteres")
+		}
+		for _, r := range code {
+			if r < 32 || r > 126 {
+				return errors.New("el código Code128 solo admite caracteres ASCII imprimibles")
+			}
+		}
+	default:
+		return errors.New("simbología inválida; use ean13, upca o code128")
+	}
+	return nil
+}
+
+// NormalizeCode quita espacios alrededor del código leído por el escáner
+func NormalizeCode(code string) string {
+	return strings.TrimSpace(code)
+}
+
+// CanonicalBarcode lleva un UPC-A (12 dígitos) a su forma EAN-13 anteponiendo un 0;
+// el resto de los códigos se devuelve sin cambios
+func CanonicalBarcode(code string) string {
+	if len(code) == 12 && isDigits(code) {
+		return "0" + code
+	}
+	return code
+}
+
+// EquivalentCodes devuelve el código junto con su equivalente UPC-A/EAN-13, si lo tiene.
+// Un escáner puede leer el mismo producto con o sin el 0 inicial.
+func EquivalentCodes(code string) []string {
+	switch {
+	case len(code) == 12 && isDigits(code):
+		return []string{code, "0" + code}
+	case len(code) == 13 && isDigits(code) && code[0] == '0':
+		return []string{code, code[1:]}
+	}
+	return []string{code}
+}
+
+// validGS1CheckDigit valida el dígito de control módulo 10 de GS1 (EAN-13 y UPC-A):
+// desde la derecha, sin contar el dígito de control, los pesos alternan 3 y 1
+func validGS1CheckDigit(code string) bool {
+	sum := 0
+	for i, weight := len(code)-2, 3; i >= 0; i, weight = i-1, 4-weight {
+		sum += int(code[i]-'0') * weight
+	}
+	return (10-sum%10)%10 == int(code[len(code)-1]-'0')
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Formato de fechas dentro de un cursor (comparable directamente en MySQL)
+const cursorTimeFormat = "2006-01-02 15:04:05.999999"
+
+// Cursor marca la posición del último elemento de una página: el valor
+// del campo de ordenamiento y el ID para desempatar.
+type Cursor struct {
+	Value interface{} `json:"v"`
+	ID    uint        `json:"id"`
+}
+
+// EncodeCursor genera un cursor opaco para el siguiente elemento después de (value, id)
+func EncodeCursor(value interface{}, id uint) string {
+	if t, ok := value.(time.Time); ok {
+		value = t.Format(cursorTimeFormat)
+	}
+
+	data, _ := json.Marshal(Cursor{Value: value, ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor interpreta un cursor generado por EncodeCursor
+func DecodeCursor(encoded string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.New("cursor inválido")
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == 0 {
+		return nil, errors.New("cursor inválido")
+	}
+
+	return &cursor, nil
+}
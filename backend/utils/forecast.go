@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"errors"
+	"math"
+)
+
+// Métodos de pronóstico soportados
+const (
+	ForecastMovingAverage = "media_movil"
+	ForecastExponential   = "suavizado"
+)
+
+// ForecastOptions configura el pronóstico de demanda
+type ForecastOptions struct {
+	Method       string  // ForecastMovingAverage o ForecastExponential
+	Window       int     // Períodos del promedio móvil
+	Alpha        float64 // Factor de suavizado exponencial, entre 0 y 1
+	SeasonLength int     // Períodos de un ciclo estacional (ej. 52 semanas); 0 sin estacionalidad
+}
+
+// Forecast es el resultado de un pronóstico
+type Forecast struct {
+	Level    float64   // Demanda base por período, sin estacionalidad
+	Seasonal bool      // Si se aplicaron índices estacionales
+	Values   []float64 // Demanda pronosticada para cada período del horizonte
+}
+
+// ForecastDemand pronostica horizon períodos a partir de la historia (del más antiguo al
+// más reciente). La estacionalidad solo se aplica si la historia cubre al menos dos ciclos
+// completos; en ese caso el nivel se calcula sobre la serie desestacionalizada.
+func ForecastDemand(history []float64, horizon int, opts ForecastOptions) (Forecast, error) {
+	if horizon <= 0 {
+		return Forecast{}, errors.New("el horizonte debe ser positivo")
+	}
+
+	var indexes []float64
+	series := history
+	if opts.SeasonLength > 1 && len(history) >= 2*opts.SeasonLength {
+		indexes = seasonalIndexes(history, opts.SeasonLength)
+	}
+	if indexes != nil {
+		// Las posiciones sin demanda histórica (índice 0) repiten el valor anterior
+		series = make([]float64, len(history))
+		for i, value := range history {
+			if index := indexes[i%opts.SeasonLength]; index > 0 {
+				series[i] = value / index
+			} else if i > 0 {
+				series[i] = series[i-1]
+			}
+		}
+	}
+
+	var level float64
+	switch opts.Method {
+	case ForecastMovingAverage:
+		if opts.Window <= 0 {
+			return Forecast{}, errors.New("la ventana del promedio móvil debe ser positiva")
+		}
+		level = MovingAverage(series, opts.Window)
+	case ForecastExponential:
+		if opts.Alpha <= 0 || opts.Alpha > 1 {
+			return Forecast{}, errors.New("alpha debe estar entre 0 y 1")
+		}
+		level = ExponentialSmoothing(series, opts.Alpha)
+	default:
+		return Forecast{}, errors.New("método de pronóstico inválido")
+	}
+
+	result := Forecast{Level: level, Seasonal: indexes != nil, Values: make([]float64, horizon)}
+	for i := range result.Values {
+		value := level
+		if indexes != nil {
+			value *= indexes[(len(history)+i)%opts.SeasonLength]
+		}
+		result.Values[i] = math.Max(0, value)
+	}
+	return result, nil
+}
+
+// MovingAverage devuelve el promedio de los últimos window valores de la serie
+func MovingAverage(series []float64, window int) float64 {
+	if len(series) == 0 || window <= 0 {
+		return 0
+	}
+	if window > len(series) {
+		window = len(series)
+	}
+	sum := 0.0
+	for _, value := range series[len(series)-window:] {
+		sum += value
+	}
+	return sum / float64(window)
+}
+
+// ExponentialSmoothing devuelve el nivel final del suavizado exponencial simple,
+// iniciado con el primer valor de la serie
+func ExponentialSmoothing(series []float64, alpha float64) float64 {
+	if len(series) == 0 {
+		return 0
+	}
+	level := series[0]
+	for _, value := range series[1:] {
+		level = alpha*value + (1-alpha)*level
+	}
+	return level
+}
+
+// seasonalIndexes calcula índices estacionales multiplicativos: para cada posición del
+// ciclo, el promedio de la demanda en esa posición dividido el promedio general. Solo se
+// usan ciclos completos, alineados al final de la serie. Devuelve nil si no hay demanda.
+func seasonalIndexes(series []float64, length int) []float64 {
+	cycles := len(series) / length
+	start := len(series) - cycles*length
+
+	total := 0.0
+	for _, value := range series[start:] {
+		total += value
+	}
+	mean := total / float64(cycles*length)
+	if mean == 0 {
+		return nil
+	}
+
+	// Los índices se indexan por posición absoluta en la serie (i % length)
+	indexes := make([]float64, length)
+	for i := start; i < len(series); i++ {
+		indexes[i%length] += series[i]
+	}
+	for i := range indexes {
+		indexes[i] = indexes[i] / float64(cycles) / mean
+	}
+	return indexes
+}
@@ -1,6 +1,9 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"os"
 	"time"
@@ -12,22 +15,46 @@ type Claims struct {
 	UserID   uint   `json:"user_id"`
 	Username string `json:"username"`
 	Role     string `json:"role"`
+	// SessionID identifica la familia de refresh tokens de la sesión que emitió el token
+	SessionID string `json:"sid"`
 	jwt.RegisteredClaims
 }
 
-// Generar token JWT (3 parámetros solamente)
-func GenerateToken(userID uint, username string, role string) (string, error) {
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 7 * 24 * time.Hour
+)
+
+// AccessTokenTTL lee la vigencia del token de acceso de ACCESS_TOKEN_TTL (ej. "15m")
+func AccessTokenTTL() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv("ACCESS_TOKEN_TTL")); err == nil && ttl > 0 {
+		return ttl
+	}
+	return defaultAccessTokenTTL
+}
+
+// RefreshTokenTTL lee la vigencia del refresh token de REFRESH_TOKEN_TTL (ej. "168h")
+func RefreshTokenTTL() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv("REFRESH_TOKEN_TTL")); err == nil && ttl > 0 {
+		return ttl
+	}
+	return defaultRefreshTokenTTL
+}
+
+// Generar token JWT de acceso asociado a la sesión sessionID
+func GenerateToken(userID uint, username string, role string, sessionID string) (string, error) {
 	secret := os.Getenv("JWT_SECRET")
 	if secret == "" {
 		return "", errors.New("JWT_SECRET no configurado")
 	}
 
 	claims := Claims{
-		UserID:   userID,
-		Username: username,
-		Role:     role,
+		UserID:    userID,
+		Username:  username,
+		Role:      role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)), // Expira en 24 horas
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenTTL())),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
@@ -57,3 +84,18 @@ func ValidateToken(tokenString string) (*Claims, error) {
 
 	return nil, errors.New("token inválido")
 }
+
+// GenerateOpaqueToken devuelve un token aleatorio en hexadecimal (refresh tokens, familias)
+func GenerateOpaqueToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// HashToken devuelve el sha256 en hexadecimal con el que se guarda un token opaco
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,268 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Límites de una hoja de Excel; el XLSX subido no puede declarar filas ni columnas fuera de ellos
+const (
+	MaxSpreadsheetRows    = 1048576
+	MaxSpreadsheetColumns = 16384
+)
+
+// ReadCSV lee todas las filas de un CSV. Detecta ";" como separador si la
+// primera línea no tiene comas (formato habitual de Excel en español).
+func ReadCSV(data []byte) ([][]string, error) {
+	return readCSV(data, MaxSpreadsheetRows)
+}
+
+func readCSV(data []byte, maxRows int) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // BOM de UTF-8
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	if !bytes.Contains(firstLine, []byte(",")) && bytes.Contains(firstLine, []byte(";")) {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var rows [][]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) >= maxRows {
+			return nil, tooManyRowsError(maxRows)
+		}
+		rows = append(rows, record)
+	}
+}
+
+// ReadXLSX lee las filas de la primera hoja de un archivo .xlsx
+func ReadXLSX(data []byte) ([][]string, error) {
+	return readXLSX(data, MaxSpreadsheetRows)
+}
+
+func readXLSX(data []byte, maxRows int) ([][]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, errors.New("el archivo no es un XLSX válido")
+	}
+
+	files := map[string]*zip.File{}
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+
+	sheetPath, err := firstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var sharedStrings []string
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if sharedStrings, err = readSharedStrings(f); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, ok := files[sheetPath]
+	if !ok {
+		return nil, errors.New("el XLSX no contiene hojas")
+	}
+	return readSheet(sheet, sharedStrings, maxRows)
+}
+
+// firstSheetPath resuelve la ruta de la primera hoja según workbook.xml y sus relaciones
+func firstSheetPath(files map[string]*zip.File) (string, error) {
+	const fallback = "xl/worksheets/sheet1.xml"
+
+	var workbook struct {
+		Sheets []struct {
+			RID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+
+	if err := decodeZipXML(files["xl/workbook.xml"], &workbook); err != nil || len(workbook.Sheets) == 0 {
+		return fallback, nil
+	}
+	if err := decodeZipXML(files["xl/_rels/workbook.xml.rels"], &rels); err != nil {
+		return fallback, nil
+	}
+
+	for _, rel := range rels.Relationships {
+		if rel.ID == workbook.Sheets[0].RID {
+			if strings.HasPrefix(rel.Target, "/") {
+				return strings.TrimPrefix(rel.Target, "/"), nil
+			}
+			return path.Join("xl", rel.Target), nil
+		}
+	}
+	return fallback, nil
+}
+
+func decodeZipXML(f *zip.File, target interface{}) error {
+	if f == nil {
+		return errors.New("archivo no encontrado")
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(rc).Decode(target)
+}
+
+// xlsxText es un texto que puede venir simple (<t>) o con formato (<r><t>)
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var sb strings.Builder
+	for _, r := range t.Runs {
+		sb.WriteString(r.T)
+	}
+	return sb.String()
+}
+
+func readSharedStrings(f *zip.File) ([]string, error) {
+	var sst struct {
+		Items []xlsxText `xml:"si"`
+	}
+	if err := decodeZipXML(f, &sst); err != nil {
+		return nil, errors.New("no se pudieron leer los textos del XLSX")
+	}
+
+	values := make([]string, len(sst.Items))
+	for i, item := range sst.Items {
+		values[i] = item.String()
+	}
+	return values, nil
+}
+
+func readSheet(f *zip.File, sharedStrings []string, maxRows int) ([][]string, error) {
+	var sheet struct {
+		Rows []struct {
+			Index int `xml:"r,attr"`
+			Cells []struct {
+				Ref    string   `xml:"r,attr"`
+				Type   string   `xml:"t,attr"`
+				Value  string   `xml:"v"`
+				Inline xlsxText `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := decodeZipXML(f, &sheet); err != nil {
+		return nil, errors.New("no se pudo leer la hoja del XLSX")
+	}
+
+	var rows [][]string
+	for _, row := range sheet.Rows {
+		// El índice viene del archivo: se valida antes de completar huecos con él
+		if row.Index > maxRows || len(rows) >= maxRows {
+			return nil, tooManyRowsError(maxRows)
+		}
+		// Las filas vacías no se guardan en el XLSX; se completan para conservar la numeración
+		for row.Index > len(rows)+1 {
+			rows = append(rows, nil)
+		}
+
+		var values []string
+		for i, cell := range row.Cells {
+			col := columnIndex(cell.Ref)
+			if col < 0 {
+				col = i
+			}
+			if col >= MaxSpreadsheetColumns {
+				return nil, errors.New("columna fuera de rango en el XLSX")
+			}
+			for len(values) < col {
+				values = append(values, "")
+			}
+
+			value := cell.Value
+			switch cell.Type {
+			case "s":
+				n, err := strconv.Atoi(cell.Value)
+				if err != nil || n < 0 || n >= len(sharedStrings) {
+					return nil, errors.New("referencia de texto inválida en el XLSX")
+				}
+				value = sharedStrings[n]
+			case "inlineStr":
+				value = cell.Inline.String()
+			}
+			values = append(values, value)
+		}
+		rows = append(rows, values)
+	}
+	return rows, nil
+}
+
+// columnIndex convierte la referencia de una celda (ej. "C7") en el índice de columna (2)
+func columnIndex(ref string) int {
+	col := 0
+	letters := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+		letters++
+		if col > MaxSpreadsheetColumns {
+			// Fuera de rango; se corta antes de que el cálculo desborde
+			return col - 1
+		}
+	}
+	if letters == 0 {
+		return -1
+	}
+	return col - 1
+}
+
+func tooManyRowsError(maxRows int) error {
+	return fmt.Errorf("el archivo supera el máximo de %d filas", maxRows)
+}
+
+// ReadSpreadsheet lee un CSV o XLSX según la extensión del nombre del archivo.
+// maxRows limita las filas del archivo, encabezado incluido.
+func ReadSpreadsheet(filename string, r io.Reader, maxRows int) ([][]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".csv":
+		return readCSV(data, maxRows)
+	case ".xlsx":
+		return readXLSX(data, maxRows)
+	}
+	return nil, errors.New("formato no soportado; use .csv o .xlsx")
+}
@@ -1,6 +1,6 @@
 import { Routes } from '@angular/router';
 import { authGuard } from './core/guards/auth.guard';
-import { adminGuard } from './core/guards/admin.guard';
+import { permissionGuard } from './core/guards/permission.guard';
 
 export const routes: Routes = [
   {
@@ -34,13 +34,13 @@ export const routes: Routes = [
     path: 'products/new',
     loadComponent: () => import('./pages/products/product-form/product-form.component')
       .then(m => m.ProductFormComponent),
-    canActivate: [authGuard, adminGuard]
+    canActivate: [authGuard, permissionGuard('products:write')]
   },
   {
     path: 'products/edit/:id',
     loadComponent: () => import('./pages/products/product-form/product-form.component')
       .then(m => m.ProductFormComponent),
-    canActivate: [authGuard]
+    canActivate: [authGuard, permissionGuard('products:update')]
   },
   {
     path: 'products/:id',
@@ -1,15 +0,0 @@
-import { inject } from '@angular/core';
-import { Router, CanActivateFn } from '@angular/router';
-import { AuthService } from '../services/auth.service';
-
-export const adminGuard: CanActivateFn = (route, state) => {
-  const authService = inject(AuthService);
-  const router = inject(Router);
-
-  if (authService.isAuthenticated() && authService.isAdmin()) {
-    return true;
-  }
-
-  router.navigate(['/dashboard']);
-  return false;
-};
\ No newline at end of file
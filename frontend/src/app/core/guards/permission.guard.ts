@@ -0,0 +1,20 @@
+import { inject } from '@angular/core';
+import { Router, CanActivateFn } from '@angular/router';
+import { catchError, map, of } from 'rxjs';
+import { AuthService } from '../services/auth.service';
+
+// Permite la ruta solo si el rol del usuario tiene el permiso indicado
+export const permissionGuard = (permission: string): CanActivateFn => (route, state) => {
+  const authService = inject(AuthService);
+  const router = inject(Router);
+
+  if (!authService.isAuthenticated()) {
+    return router.createUrlTree(['/login'], { queryParams: { returnUrl: state.url } });
+  }
+
+  // Los permisos se piden a /auth/profile la primera vez que hacen falta
+  return authService.loadPermissions().pipe(
+    map(() => authService.hasPermission(permission) || router.createUrlTree(['/dashboard'])),
+    catchError(() => of(router.createUrlTree(['/dashboard'])))
+  );
+};
@@ -0,0 +1,80 @@
+import { TestBed } from '@angular/core/testing';
+import { HttpClient, provideHttpClient, withInterceptors } from '@angular/common/http';
+import { HttpTestingController, provideHttpClientTesting } from '@angular/common/http/testing';
+import { provideRouter } from '@angular/router';
+import { forkJoin } from 'rxjs';
+import { authInterceptor } from './auth.interceptor';
+import { environment } from '../../../environments/environment';
+
+describe('authInterceptor', () => {
+  let http: HttpClient;
+  let httpMock: HttpTestingController;
+
+  beforeEach(() => {
+    localStorage.setItem('auth_token', 'expired-access');
+    localStorage.setItem('refresh_token', 'refresh-1');
+
+    TestBed.configureTestingModule({
+      providers: [
+        provideHttpClient(withInterceptors([authInterceptor])),
+        provideHttpClientTesting(),
+        provideRouter([])
+      ]
+    });
+
+    http = TestBed.inject(HttpClient);
+    httpMock = TestBed.inject(HttpTestingController);
+  });
+
+  afterEach(() => {
+    httpMock.verify();
+    localStorage.clear();
+  });
+
+  it('should refresh once for parallel requests with an expired access token', () => {
+    const urls = ['/a', '/b', '/c', '/d', '/e'].map(path => `${environment.apiUrl}${path}`);
+    let results: unknown[] | undefined;
+
+    forkJoin(urls.map(url => http.get(url))).subscribe(res => results = res);
+
+    // Todas las peticiones salen con el token vencido y reciben 401
+    for (const url of urls) {
+      const req = httpMock.expectOne(url);
+      expect(req.request.headers.get('Authorization')).toBe('Bearer expired-access');
+      req.flush({ error: 'Token inválido' }, { status: 401, statusText: 'Unauthorized' });
+    }
+
+    // Un único canje del refresh token
+    const refresh = httpMock.expectOne(`${environment.apiUrl}/auth/refresh`);
+    expect(refresh.request.body).toEqual({ refresh_token: 'refresh-1' });
+    refresh.flush({ token: 'new-access', refresh_token: 'refresh-2', expires_in: 900 });
+
+    // Cada petición se reintenta con el token nuevo
+    urls.forEach((url, i) => {
+      const req = httpMock.expectOne(url);
+      expect(req.request.headers.get('Authorization')).toBe('Bearer new-access');
+      req.flush({ n: i });
+    });
+
+    expect(results).toEqual([{ n: 0 }, { n: 1 }, { n: 2 }, { n: 3 }, { n: 4 }]);
+    expect(localStorage.getItem('refresh_token')).toBe('refresh-2');
+  });
+
+  it('should retry without refreshing when another request already renewed the token', () => {
+    const url = `${environment.apiUrl}/a`;
+    let result: unknown;
+
+    http.get(url).subscribe(res => result = res);
+
+    const first = httpMock.expectOne(url);
+    localStorage.setItem('auth_token', 'new-access');
+    first.flush({ error: 'Token inválido' }, { status: 401, statusText: 'Unauthorized' });
+
+    httpMock.expectNone(`${environment.apiUrl}/auth/refresh`);
+    const retry = httpMock.expectOne(url);
+    expect(retry.request.headers.get('Authorization')).toBe('Bearer new-access');
+    retry.flush({ ok: true });
+
+    expect(result).toEqual({ ok: true });
+  });
+});
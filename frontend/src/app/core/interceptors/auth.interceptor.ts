@@ -1,16 +1,39 @@
-import { HttpInterceptorFn } from '@angular/common/http';
+import { HttpErrorResponse, HttpInterceptorFn, HttpRequest } from '@angular/common/http';
+import { inject } from '@angular/core';
+import { catchError, switchMap, throwError } from 'rxjs';
+import { AuthService } from '../services/auth.service';
 
-export const authInterceptor: HttpInterceptorFn = (req, next) => {
+const withToken = (req: HttpRequest<unknown>): HttpRequest<unknown> => {
   // Leer directamente de localStorage evita problemas de timing con injección del servicio
   const token = localStorage.getItem('auth_token');
+  return token ? req.clone({ setHeaders: { Authorization: `Bearer ${token}` } }) : req;
+};
+
+export const authInterceptor: HttpInterceptorFn = (req, next) => {
+  const authService = inject(AuthService);
+
+  const sent = withToken(req);
+
+  return next(sent).pipe(
+    catchError((error: HttpErrorResponse) => {
+      // El token de acceso dura poco: ante un 401 se intenta renovar la sesión una vez
+      if (error.status !== 401 || /\/auth\/(login|register|refresh|logout)$/.test(req.url) || !authService.hasRefreshToken()) {
+        return throwError(() => error);
+      }
 
-  if (token) {
-    req = req.clone({
-      setHeaders: {
-        Authorization: `Bearer ${token}`
+      // Otra petición ya renovó la sesión mientras esta viajaba con el token anterior
+      if (sent.headers.get('Authorization') !== `Bearer ${authService.getToken()}`) {
+        return next(withToken(req));
       }
-    });
-  }
 
-  return next(req);
-};
\ No newline at end of file
+      // Las peticiones que fallan a la vez esperan la misma renovación
+      return authService.refreshSession().pipe(
+        catchError(refreshError => {
+          authService.clearSession();
+          return throwError(() => refreshError);
+        }),
+        switchMap(() => next(withToken(req)))
+      );
+    })
+  );
+};
@@ -1,13 +1,14 @@
 import { Injectable } from '@angular/core';
 import { HttpClient } from '@angular/common/http';
-import { BehaviorSubject, Observable, tap } from 'rxjs';
+import { BehaviorSubject, Observable, finalize, map, of, shareReplay, tap } from 'rxjs';
 import { Router } from '@angular/router';
 import { environment } from '../../../environments/environment';
 import { 
   User, 
   LoginRequest, 
   RegisterRequest, 
-  AuthResponse 
+  AuthResponse,
+  RefreshResponse
 } from '../../shared/models/user.model';
 
 @Injectable({
@@ -16,8 +17,13 @@ import {
 export class AuthService {
   private readonly API_URL = environment.apiUrl;
   private readonly TOKEN_KEY = 'auth_token';
+  private readonly REFRESH_KEY = 'refresh_token';
   private readonly USER_KEY = 'current_user';
 
+  // Renovación en curso: las peticiones que reciben 401 a la vez comparten el mismo canje,
+  // porque presentar dos veces el mismo refresh token revoca la sesión completa
+  private refreshInFlight: Observable<RefreshResponse> | null = null;
+
   private currentUserSubject = new BehaviorSubject<User | null>(this.getUserFromStorage());
   public currentUser$ = this.currentUserSubject.asObservable();
 
@@ -37,12 +43,41 @@ export class AuthService {
   }
 
   logout(): void {
+    // Revocar la sesión en el servidor; se limpia localmente aunque la llamada falle
+    if (this.getToken()) {
+      this.http.post(`${this.API_URL}/auth/logout`, {}).subscribe({ error: () => {} });
+    }
+    this.clearSession();
+  }
+
+  clearSession(): void {
     localStorage.removeItem(this.TOKEN_KEY);
+    localStorage.removeItem(this.REFRESH_KEY);
     localStorage.removeItem(this.USER_KEY);
     this.currentUserSubject.next(null);
     this.router.navigate(['/login']);
   }
 
+  refreshSession(): Observable<RefreshResponse> {
+    if (!this.refreshInFlight) {
+      this.refreshInFlight = this.http.post<RefreshResponse>(`${this.API_URL}/auth/refresh`, {
+        refresh_token: localStorage.getItem(this.REFRESH_KEY)
+      }).pipe(
+        tap(response => {
+          localStorage.setItem(this.TOKEN_KEY, response.token);
+          localStorage.setItem(this.REFRESH_KEY, response.refresh_token);
+        }),
+        finalize(() => this.refreshInFlight = null),
+        shareReplay(1)
+      );
+    }
+    return this.refreshInFlight;
+  }
+
+  hasRefreshToken(): boolean {
+    return !!localStorage.getItem(this.REFRESH_KEY);
+  }
+
   getProfile(): Observable<{ user: User }> {
     return this.http.get<{ user: User }>(`${this.API_URL}/auth/profile`);
   }
@@ -59,19 +94,38 @@ export class AuthService {
     return this.currentUserSubject.value;
   }
 
-  isAdmin(): boolean {
+  // Los permisos del rol deciden qué acciones se muestran; "*" los incluye todos
+  hasPermission(permission: string): boolean {
+    const permissions = this.getCurrentUser()?.permissions ?? [];
+    return permissions.includes('*') || permissions.includes(permission);
+  }
+
+  // Completa los permisos del usuario con /auth/profile (el login no los incluye)
+  loadPermissions(): Observable<string[]> {
     const user = this.getCurrentUser();
-    return user?.role === 'admin';
+    if (user?.permissions) {
+      return of(user.permissions);
+    }
+    return this.getProfile().pipe(
+      tap(({ user: profile }) => this.setCurrentUser({ ...user, ...profile })),
+      map(({ user: profile }) => profile.permissions ?? [])
+    );
   }
 
   private handleAuthResponse(response: AuthResponse): void {
     if (response.token) {
       localStorage.setItem(this.TOKEN_KEY, response.token);
-      localStorage.setItem(this.USER_KEY, JSON.stringify(response.user));
-      this.currentUserSubject.next(response.user);
+      localStorage.setItem(this.REFRESH_KEY, response.refresh_token);
+      this.setCurrentUser(response.user);
+      this.loadPermissions().subscribe({ error: () => {} });
     }
   }
 
+  private setCurrentUser(user: User): void {
+    localStorage.setItem(this.USER_KEY, JSON.stringify(user));
+    this.currentUserSubject.next(user);
+  }
+
   private getUserFromStorage(): User | null {
     const userJson = localStorage.getItem(this.USER_KEY);
     return userJson ? JSON.parse(userJson) : null;
@@ -1,8 +1,9 @@
 import { Injectable } from '@angular/core';
-import { HttpClient } from '@angular/common/http';
-import { Observable } from 'rxjs';
+import { HttpClient, HttpParams } from '@angular/common/http';
+import { Observable, map } from 'rxjs';
 import { environment } from '../../../environments/environment';
 import { Category, CategoryResponse, CategoryRequest } from '../../shared/models/category.model';
+import { FULL_LIST_PAGE_SIZE, fetchAllPages } from '../../shared/utils/pagination';
 
 @Injectable({
   providedIn: 'root'
@@ -12,8 +13,19 @@ export class CategoryService {
 
   constructor(private http: HttpClient) {}
 
+  // El listado está paginado; getAll recorre todas las páginas
   getAll(): Observable<CategoryResponse> {
-    return this.http.get<CategoryResponse>(this.API_URL);
+    return fetchAllPages(cursor => this.getPage(cursor), page => page.categories).pipe(
+      map(categories => ({ categories, total: categories.length }))
+    );
+  }
+
+  getPage(cursor?: string, limit = FULL_LIST_PAGE_SIZE): Observable<CategoryResponse> {
+    let params = new HttpParams().set('limit', limit);
+    if (cursor) {
+      params = params.set('cursor', cursor);
+    }
+    return this.http.get<CategoryResponse>(this.API_URL, { params });
   }
 
   getById(id: number): Observable<{ category: Category }> {
@@ -1,5 +1,5 @@
 import { Injectable } from '@angular/core';
-import { HttpClient } from '@angular/common/http';
+import { HttpClient, HttpParams } from '@angular/common/http';
 import { Observable } from 'rxjs';
 import { environment } from '../../../environments/environment';
 import { Movement, MovementRequest, MovementResponse } from '../../shared/models/movement.model';
@@ -12,27 +12,40 @@ export class MovementService {
 
   constructor(private http: HttpClient) {}
 
-  getAll(): Observable<MovementResponse> {
-    return this.http.get<MovementResponse>(this.API_URL);
+  // Los listados de movimientos están paginados: cada llamada trae una página y
+  // next_cursor, si viene, pide la siguiente
+  getAll(cursor?: string): Observable<MovementResponse> {
+    return this.http.get<MovementResponse>(this.API_URL, { params: this.pageParams(cursor) });
   }
 
   getById(id: number): Observable<{ movement: Movement }> {
     return this.http.get<{ movement: Movement }>(`${this.API_URL}/${id}`);
   }
 
-  getByType(type: 'entrada' | 'salida'): Observable<MovementResponse> {
-    return this.http.get<MovementResponse>(`${this.API_URL}/type/${type}`);
+  getByType(type: 'entrada' | 'salida', cursor?: string): Observable<MovementResponse> {
+    return this.http.get<MovementResponse>(`${this.API_URL}/type/${type}`, { params: this.pageParams(cursor) });
   }
 
-  getByProduct(productId: number): Observable<MovementResponse> {
-    return this.http.get<MovementResponse>(`${this.API_URL}/product/${productId}`);
+  getByProduct(productId: number, cursor?: string, limit?: number): Observable<MovementResponse> {
+    return this.http.get<MovementResponse>(`${this.API_URL}/product/${productId}`, { params: this.pageParams(cursor, limit) });
   }
 
   create(data: MovementRequest): Observable<{ message: string; movement: Movement; nuevo_stock: number }> {
     return this.http.post<{ message: string; movement: Movement; nuevo_stock: number }>(this.API_URL, data);
   }
 
-  delete(id: number): Observable<{ message: string }> {
-    return this.http.delete<{ message: string }>(`${this.API_URL}/${id}`);
+  reverse(id: number, description?: string): Observable<{ message: string; movement: Movement; nuevo_stock: number }> {
+    return this.http.post<{ message: string; movement: Movement; nuevo_stock: number }>(`${this.API_URL}/${id}/reverse`, { description });
   }
-}
\ No newline at end of file
+
+  private pageParams(cursor?: string, limit?: number): HttpParams {
+    let params = new HttpParams();
+    if (cursor) {
+      params = params.set('cursor', cursor);
+    }
+    if (limit) {
+      params = params.set('limit', limit);
+    }
+    return params;
+  }
+}
@@ -1,8 +1,9 @@
 import { Injectable } from '@angular/core';
-import { HttpClient } from '@angular/common/http';
-import { Observable } from 'rxjs';
+import { HttpClient, HttpParams } from '@angular/common/http';
+import { Observable, map } from 'rxjs';
 import { environment } from '../../../environments/environment';
 import { Product, ProductResponse, ProductRequest } from '../../shared/models/product.model';
+import { FULL_LIST_PAGE_SIZE, fetchAllPages } from '../../shared/utils/pagination';
 
 @Injectable({
   providedIn: 'root'
@@ -12,8 +13,19 @@ export class ProductService {
 
   constructor(private http: HttpClient) {}
 
+  // El listado está paginado; getAll recorre todas las páginas
   getAll(): Observable<ProductResponse> {
-    return this.http.get<ProductResponse>(this.API_URL);
+    return fetchAllPages(cursor => this.getPage(cursor), page => page.products).pipe(
+      map(products => ({ products, total: products.length }))
+    );
+  }
+
+  getPage(cursor?: string, limit = FULL_LIST_PAGE_SIZE): Observable<ProductResponse> {
+    let params = new HttpParams().set('limit', limit);
+    if (cursor) {
+      params = params.set('cursor', cursor);
+    }
+    return this.http.get<ProductResponse>(this.API_URL, { params });
   }
 
   getById(id: number): Observable<{ product: Product }> {
@@ -32,8 +44,9 @@ export class ProductService {
     return this.http.post<{ message: string; product: Product }>(this.API_URL, data);
   }
 
-  update(id: number, data: ProductRequest): Observable<{ message: string; product: Product }> {
-    return this.http.put<{ message: string; product: Product }>(`${this.API_URL}/${id}`, data);
+  // PATCH: solo se modifican los campos enviados; el stock cambia únicamente con movimientos
+  update(id: number, data: Partial<ProductRequest>): Observable<{ message: string; product: Product }> {
+    return this.http.patch<{ message: string; product: Product }>(`${this.API_URL}/${id}`, data);
   }
 
   delete(id: number): Observable<{ message: string }> {
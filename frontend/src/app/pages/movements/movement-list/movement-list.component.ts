@@ -1,6 +1,7 @@
 import { Component, OnInit } from '@angular/core';
 import { CommonModule } from '@angular/common';
 import { Router } from '@angular/router';
+import { Observable } from 'rxjs';
 import { MatSidenavModule } from '@angular/material/sidenav';
 import { MatCardModule } from '@angular/material/card';
 import { MatTableModule } from '@angular/material/table';
@@ -12,7 +13,7 @@ import { NavbarComponent } from '../../../shared/components/navbar/navbar.compon
 import { SidebarComponent } from '../../../shared/components/sidebar/sidebar.component';
 import { LoadingComponent } from '../../../shared/components/loading/loading.component';
 import { MovementService } from '../../../core/services/movement.service';
-import { Movement } from '../../../shared/models/movement.model';
+import { Movement, MovementResponse } from '../../../shared/models/movement.model';
 
 @Component({
   selector: 'app-movement-list',
@@ -35,8 +36,11 @@ import { Movement } from '../../../shared/models/movement.model';
 })
 export class MovementListComponent implements OnInit {
   loading = true;
+  loadingMore = false;
   sidebarOpened = true;
   movements: Movement[] = [];
+  total = 0;
+  nextCursor?: string;
   filterType: 'all' | 'entrada' | 'salida' = 'all';
   displayedColumns: string[] = ['product', 'type', 'quantity', 'user', 'date'];
 
@@ -46,12 +50,14 @@ export class MovementListComponent implements OnInit {
     this.loadMovements();
   }
 
+  // El listado está paginado: se carga la primera página y el resto con "Cargar más"
   loadMovements(): void {
     this.loading = true;
-    this.movementService.getAll().subscribe({
+    this.fetchPage().subscribe({
       next: (res) => {
         this.movements = res.movements;
-        this.applyFilter();
+        this.total = res.total;
+        this.nextCursor = res.next_cursor;
         this.loading = false;
       },
       error: (err) => {
@@ -61,12 +67,28 @@ export class MovementListComponent implements OnInit {
     });
   }
 
-  applyFilter(): void {
-    if (this.filterType === 'all') {
-      // already set
-    } else {
-      this.movements = this.movements.filter(m => m.type === this.filterType);
-    }
+  loadMore(): void {
+    if (!this.nextCursor) return;
+
+    this.loadingMore = true;
+    this.fetchPage(this.nextCursor).subscribe({
+      next: (res) => {
+        this.movements = [...this.movements, ...res.movements];
+        this.nextCursor = res.next_cursor;
+        this.loadingMore = false;
+      },
+      error: (err) => {
+        console.error('Error cargando movimientos:', err);
+        this.loadingMore = false;
+      }
+    });
+  }
+
+  // El filtro por tipo se aplica en el servidor para que la paginación sea correcta
+  private fetchPage(cursor?: string): Observable<MovementResponse> {
+    return this.filterType === 'all'
+      ? this.movementService.getAll(cursor)
+      : this.movementService.getByType(this.filterType, cursor);
   }
 
   onTypeChange(type: 'all' | 'entrada' | 'salida'): void {
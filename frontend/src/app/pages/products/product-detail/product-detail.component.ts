@@ -82,9 +82,10 @@ export class ProductDetailComponent implements OnInit {
   }
 
   loadMovements(id: number): void {
-    this.movementService.getByProduct(id).subscribe({
+    // Solo los 10 más recientes (el listado se ordena por fecha descendente)
+    this.movementService.getByProduct(id, undefined, 10).subscribe({
       next: (res) => {
-        this.recentMovements = res.movements.slice(0, 10);
+        this.recentMovements = res.movements;
       },
       error: (err) => console.error('Error cargando movimientos:', err)
     });
@@ -103,6 +103,9 @@ export class ProductFormComponent implements OnInit {
           stock: product.stock,
           image_url: product.image_url
         });
+        // El stock de un producto existente solo cambia con movimientos; al estar
+        // deshabilitado no se incluye en el formulario enviado
+        this.productForm.get('stock')?.disable();
         this.loading = false;
       },
       error: (error) => {
@@ -40,6 +40,16 @@ export class NavbarComponent {
     this.toggleSidebar.emit();
   }
 
+  hasAllPermissions(): boolean {
+    return this.authService.hasPermission('*');
+  }
+
+  // Los roles del sistema tienen nombre en español; los demás se muestran tal cual
+  roleLabel(): string {
+    const labels: Record<string, string> = { admin: 'Administrador', employee: 'Empleado' };
+    return labels[this.currentUser?.role ?? ''] ?? this.currentUser?.role ?? '';
+  }
+
   logout(): void {
     this.authService.logout();
   }
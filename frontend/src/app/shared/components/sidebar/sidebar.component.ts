@@ -10,7 +10,7 @@ interface MenuItem {
   title: string;
   icon: string;
   route: string;
-  adminOnly?: boolean;
+  permission?: string; // Solo se muestra si el rol tiene este permiso
 }
 
 @Component({
@@ -40,9 +40,6 @@ export class SidebarComponent {
   constructor(public authService: AuthService) {}
 
   shouldShowItem(item: MenuItem): boolean {
-    if (item.adminOnly) {
-      return this.authService.isAdmin();
-    }
-    return true;
+    return !item.permission || this.authService.hasPermission(item.permission);
   }
 }
\ No newline at end of file
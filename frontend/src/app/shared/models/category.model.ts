@@ -2,11 +2,16 @@ export interface Category {
   id: number;
   name: string;
   description: string;
+  default_min_stock?: number | null;
+  default_max_stock?: number | null;
+  default_reorder_point?: number | null;
   created_at?: string;
 }
 
 export interface CategoryResponse {
   categories: Category[];
+  total: number;
+  next_cursor?: string;
 }
 
 export interface CategoryRequest {
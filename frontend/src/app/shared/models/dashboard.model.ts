@@ -5,6 +5,7 @@ export interface DashboardStats {
   low_stock_products: number;
   total_users: number;
   total_inventory_value: number;
+  total_retail_value: number;
 }
 
 export interface MovementSummary {
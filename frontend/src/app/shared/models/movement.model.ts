@@ -7,9 +7,13 @@ export interface Movement {
   product?: Product;
   user_id: number;
   user?: User;
-  type: 'entrada' | 'salida';
+  type: 'entrada' | 'salida' | 'reverso_entrada' | 'reverso_salida';
   quantity: number;
   description?: string;
+  reversal_of_id?: number;
+  unit_cost?: number;
+  cost_amount?: number;
+  fifo_cost?: number;
   movement_date: string;
 }
 
@@ -17,10 +21,12 @@ export interface MovementRequest {
   product_id: number;
   type: 'entrada' | 'salida';
   quantity: number;
+  unit_cost?: number;
   description?: string;
 }
 
 export interface MovementResponse {
   movements: Movement[];
   total: number;
+  next_cursor?: string;
 }
\ No newline at end of file
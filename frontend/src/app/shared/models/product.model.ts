@@ -8,6 +8,13 @@ export interface Product {
   category?: Category;
   price: number;
   stock: number;
+  on_order?: number;
+  reserved?: number;
+  available?: number;
+  average_cost?: number;
+  min_stock?: number | null;
+  max_stock?: number | null;
+  reorder_point?: number | null;
   image_url?: string;
   created_at?: string;
   updated_at?: string;
@@ -16,6 +23,7 @@ export interface Product {
 export interface ProductResponse {
   products: Product[];
   total: number;
+  next_cursor?: string;
 }
 
 export interface ProductRequest {
@@ -24,5 +32,6 @@ export interface ProductRequest {
   category_id?: number;
   price: number;
   stock: number;
+  average_cost?: number;
   image_url?: string;
 }
\ No newline at end of file
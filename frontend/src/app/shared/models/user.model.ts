@@ -2,9 +2,12 @@ export interface User {
   id: number;
   username: string;
   email: string;
-  role: 'admin' | 'employee';
+  role: string; // Nombre del rol (admin, employee u otro definido por un administrador)
+  permissions?: string[];
+  active?: boolean;
   created_at?: string;
   updated_at?: string;
+  deleted_at?: string | null;
 }
 
 export interface LoginRequest {
@@ -16,11 +19,18 @@ export interface RegisterRequest {
   username: string;
   email: string;
   password: string;
-  role?: 'admin' | 'employee';
 }
 
 export interface AuthResponse {
   token: string;
+  refresh_token: string;
+  expires_in: number;
   user: User;
   message: string;
+}
+
+export interface RefreshResponse {
+  token: string;
+  refresh_token: string;
+  expires_in: number;
 }
\ No newline at end of file
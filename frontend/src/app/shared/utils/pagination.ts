@@ -0,0 +1,21 @@
+import { EMPTY, Observable, expand, reduce } from 'rxjs';
+
+// Página de un listado paginado por cursor
+export interface CursorPage {
+  next_cursor?: string;
+}
+
+// Tamaño de página al recorrer un listado completo (el máximo que acepta la API)
+export const FULL_LIST_PAGE_SIZE = 500;
+
+// Recorre todas las páginas de un listado siguiendo next_cursor y junta sus elementos.
+// Para catálogos acotados (productos, categorías); los movimientos se paginan en pantalla.
+export function fetchAllPages<P extends CursorPage, T>(
+  fetchPage: (cursor?: string) => Observable<P>,
+  items: (page: P) => T[]
+): Observable<T[]> {
+  return fetchPage().pipe(
+    expand(page => page.next_cursor ? fetchPage(page.next_cursor) : EMPTY),
+    reduce((all, page) => all.concat(items(page)), [] as T[])
+  );
+}